@@ -0,0 +1,271 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"mosn.io/holmes/internal/inspect"
+)
+
+// profileBaselines holds the binary profiles captured in a quiet period, keyed by the profile name.
+type profileBaselines struct {
+	mu         sync.RWMutex
+	profiles   map[string][]byte
+	capturedAt time.Time
+	// the time of the latest dump, the baseline is only refreshed in a quiet period.
+	lastDumpAt time.Time
+
+	// cancel the cpu baseline sampling in flight, and closed when it's done.
+	cpuMu     sync.Mutex
+	cpuCancel context.CancelFunc
+	cpuDone   chan struct{}
+}
+
+func (b *profileBaselines) get(name string) []byte {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.profiles[name]
+}
+
+func (b *profileBaselines) set(profiles map[string][]byte, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.profiles = profiles
+	b.capturedAt = now
+}
+
+// setProfile sets the baseline of the profile, keeping the others.
+func (b *profileBaselines) setProfile(name string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	profiles := make(map[string][]byte, len(b.profiles)+1)
+	for k, v := range b.profiles {
+		profiles[k] = v
+	}
+	profiles[name] = data
+	b.profiles = profiles
+}
+
+// startCPU returns the context of the cpu baseline sampling, false means it's in flight already.
+func (b *profileBaselines) startCPU(ctx context.Context) (context.Context, bool) {
+	b.cpuMu.Lock()
+	defer b.cpuMu.Unlock()
+	if b.cpuDone != nil {
+		return nil, false
+	}
+	ctx, b.cpuCancel = context.WithCancel(ctx)
+	b.cpuDone = make(chan struct{})
+	return ctx, true
+}
+
+// finishCPU marks the cpu baseline sampling done.
+func (b *profileBaselines) finishCPU() {
+	b.cpuMu.Lock()
+	defer b.cpuMu.Unlock()
+	b.cpuCancel()
+	close(b.cpuDone)
+	b.cpuCancel, b.cpuDone = nil, nil
+}
+
+// stopCPU cancels the cpu baseline sampling in flight and waits for it, since only one cpu profile could be running.
+func (b *profileBaselines) stopCPU() {
+	b.cpuMu.Lock()
+	cancel, done := b.cpuCancel, b.cpuDone
+	b.cpuMu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (b *profileBaselines) markDump(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastDumpAt = now
+}
+
+// quietSince returns the time since when neither the baseline is captured nor any profile is dumped.
+func (b *profileBaselines) quietSince() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.lastDumpAt.After(b.capturedAt) {
+		return b.lastDumpAt
+	}
+	return b.capturedAt
+}
+
+type profileDiffType struct {
+	diff       configureType
+	top        configureType
+	sampleType string
+}
+
+// profile name to the types of the diff files, and the sample type to rank the top n.
+var profileDiffTypes = map[string]profileDiffType{
+	"heap":      {heapDiff, heapDiffTop, "inuse_space"},
+	"goroutine": {goroutineDiff, goroutineDiffTop, "goroutine"},
+	"cpu":       {cpuDiff, cpuDiffTop, "cpu"},
+}
+
+// baselineProfileNames returns the profiles to capture as the baseline,
+// the heap baseline is also captured for WithHeapDiff.
+func (h *Holmes) baselineProfileNames() []string {
+	if h.opts.GetBaselineOpts().Enable {
+		return []string{"heap", "goroutine", "cpu"}
+	}
+	if h.opts.HeapDiffTopN > 0 {
+		return []string{"heap"}
+	}
+	return nil
+}
+
+func (h *Holmes) diffTopN() int {
+	if n := h.opts.HeapDiffTopN; n > 0 {
+		return n
+	}
+	return defaultDiffTopN
+}
+
+// captureBaselines captures the binary baseline profiles,
+// the cpu baseline is sampled in another goroutine, not to block the checks in the dump loop.
+func (h *Holmes) captureBaselines() {
+	names := h.baselineProfileNames()
+	if len(names) == 0 {
+		return
+	}
+
+	profiles := make(map[string][]byte, len(names))
+	sampleCPU := false
+	for _, name := range names {
+		if name == "cpu" {
+			sampleCPU = true
+			// keep the previous one until the new one is sampled.
+			if prev := h.baselines.get(name); prev != nil {
+				profiles[name] = prev
+			}
+			continue
+		}
+		var buf bytes.Buffer
+		if err := pprof.Lookup(name).WriteTo(&buf, int(binaryDump)); err != nil {
+			h.Errorf("[Holmes] failed to capture %v baseline: %v", name, err)
+			continue
+		}
+		profiles[name] = buf.Bytes()
+	}
+	h.baselines.set(profiles, h.now())
+	h.Infof("[Holmes] baseline profiles %v captured", names)
+
+	if sampleCPU {
+		h.captureCPUBaseline()
+	}
+}
+
+// captureCPUBaseline samples the cpu baseline for CPUSamplingTime in another goroutine,
+// it's canceled by stop, or by a cpu dump since only one cpu profile could be running.
+func (h *Holmes) captureCPUBaseline() {
+	ctx, ok := h.baselines.startCPU(h.runContext())
+	if !ok {
+		return
+	}
+
+	h.goWait(func() {
+		defer h.baselines.finishCPU()
+
+		var buf bytes.Buffer
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			h.Errorf("[Holmes] failed to capture cpu baseline: %v", err)
+			return
+		}
+		completed := h.sleepContext(ctx, h.opts.CPUSamplingTime)
+		pprof.StopCPUProfile()
+		if !completed {
+			h.Infof("[Holmes] cpu baseline sampling is canceled")
+			return
+		}
+		h.baselines.setProfile("cpu", buf.Bytes())
+		h.Infof("[Holmes] cpu baseline profile captured")
+	})
+}
+
+// refreshBaselines captures the baseline again when it's quiet in the recent refresh interval.
+func (h *Holmes) refreshBaselines() {
+	baselineOpts := h.opts.GetBaselineOpts()
+	if !baselineOpts.Enable || baselineOpts.RefreshInterval <= 0 {
+		return
+	}
+	if h.now().Sub(h.baselines.quietSince()) < baselineOpts.RefreshInterval {
+		return
+	}
+	h.captureBaselines()
+}
+
+// diffWithBaseline writes the diff of the dumped profile with the latest baseline of the same profile.
+func (h *Holmes) diffWithBaseline(name string, data []byte, eventID string) {
+	base := h.baselines.get(name)
+	if base == nil {
+		return
+	}
+	h.writeProfileDiff(name, base, h.binaryProfile(name, data), eventID, "baseline")
+}
+
+// binaryProfile returns the profile in binary, it's dumped again when the data is in text.
+func (h *Holmes) binaryProfile(name string, data []byte) []byte {
+	if inspect.IsBinaryProfile(data) {
+		return data
+	}
+	var bin bytes.Buffer
+	_ = pprof.Lookup(name).WriteTo(&bin, int(binaryDump)) // nolint: errcheck
+	return bin.Bytes()
+}
+
+// writeProfileDiff writes the delta profile of cur - base, and the top n functions which grow most.
+// base name is used to distinguish the diffs in the same event.
+func (h *Holmes) writeProfileDiff(name string, base, cur []byte, eventID string, baseName string) {
+	types, ok := profileDiffTypes[name]
+	if !ok {
+		return
+	}
+
+	diff, err := inspect.Delta(base, cur)
+	if err != nil {
+		h.Errorf("[Holmes] failed to diff %v profile with %v: %v", name, baseName, err)
+		return
+	}
+
+	if eventID == "" {
+		eventID = baseName
+	} else {
+		eventID = eventID + "." + baseName
+	}
+
+	var buf bytes.Buffer
+	if err := diff.Write(&buf); err != nil {
+		h.Errorf("[Holmes] failed to write %v diff profile: %v", name, err)
+		return
+	}
+	h.writeProfileDataToFile(buf, types.diff, eventID)
+
+	top := inspect.FormatDeltaTop(diff, types.sampleType, h.diffTopN())
+	h.Infof("[Holmes] %v diff with %v:\n%s", name, baseName, top.String())
+	h.writeProfileDataToFile(top, types.top, eventID)
+}
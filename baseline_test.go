@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/holmes/internal/inspect"
+)
+
+func TestProfileBaselinesQuietSince(t *testing.T) {
+	var b profileBaselines
+	now := time.Now()
+	assert.True(t, b.quietSince().IsZero())
+	assert.Nil(t, b.get("heap"))
+
+	b.set(map[string][]byte{"heap": []byte("heap")}, now)
+	assert.Equal(t, now, b.quietSince())
+	assert.Equal(t, []byte("heap"), b.get("heap"))
+
+	b.markDump(now.Add(time.Minute))
+	assert.Equal(t, now.Add(time.Minute), b.quietSince())
+
+	b.set(map[string][]byte{}, now.Add(2*time.Minute))
+	assert.Equal(t, now.Add(2*time.Minute), b.quietSince())
+	assert.Nil(t, b.get("heap"))
+}
+
+// newTestCPUProfile builds a cpu profile with a single function sampled value nanoseconds in duration.
+func newTestCPUProfile(t *testing.T, value int64, duration time.Duration) []byte {
+	fn := &profile.Function{ID: 1, Name: "main.busy", Filename: "main.go"}
+	loc := &profile.Location{ID: 1, Line: []profile.Line{{Function: fn, Line: 1}}}
+	p := &profile.Profile{
+		SampleType:    []*profile.ValueType{{Type: "cpu", Unit: "nanoseconds"}},
+		PeriodType:    &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		DurationNanos: int64(duration),
+		Function:      []*profile.Function{fn},
+		Location:      []*profile.Location{loc},
+		Sample:        []*profile.Sample{{Location: []*profile.Location{loc}, Value: []int64{value}}},
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, p.Write(&buf))
+	return buf.Bytes()
+}
+
+func TestDiffProfilesScaleByDuration(t *testing.T) {
+	p := newTestCPUProfile(t, 100, time.Second)
+	base := newTestCPUProfile(t, 100, 2*time.Second)
+
+	diff, err := inspect.Delta(base, p)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(50), inspect.AggregateByFunc(diff, 0)[0].Flat)
+}
+
+func TestCaptureCPUBaseline(t *testing.T) {
+	h, err := New(WithBaselineProfiles(time.Hour), WithCPUSamplingTime("100ms"))
+	assert.Nil(t, err)
+
+	// the cpu baseline doesn't block the dump loop.
+	start := time.Now()
+	h.captureBaselines()
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+	assert.NotNil(t, h.baselines.get("heap"))
+	assert.NotNil(t, h.baselines.get("goroutine"))
+
+	h.wg.Wait()
+	cpuBaseline := h.baselines.get("cpu")
+	assert.NotNil(t, cpuBaseline)
+
+	// the sampling is canceled by a cpu dump, and the previous cpu baseline is kept.
+	assert.Nil(t, h.Set(WithCPUSamplingTime("1m")))
+	h.captureBaselines()
+	start = time.Now()
+	h.baselines.stopCPU()
+	h.wg.Wait()
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, cpuBaseline, h.baselines.get("cpu"))
+}
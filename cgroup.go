@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isCGroupV2 returns whether the unified cgroup hierarchy(cgroup v2) is mounted.
+func isCGroupV2() bool {
+	_, err := os.Stat(cgroupV2ControllersPath)
+	return err == nil
+}
+
+// readFlatKeyed reads the cgroup file in flat keyed format, like memory.events and cpu.stat:
+//
+//	key1 value1
+//	key2 value2
+func readFlatKeyed(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+
+	return parseFlatKeyed(f)
+}
+
+func parseFlatKeyed(r io.Reader) (map[string]uint64, error) {
+	kv := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := parseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %v: %w", fields[0], err)
+		}
+		kv[fields[0]] = v
+	}
+	return kv, scanner.Err()
+}
+
+// readMax reads the cgroup v2 file like memory.max, 0 means there is no limit.
+func readMax(path string) (uint64, error) {
+	v, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(v))
+	if s == "max" {
+		return 0, nil
+	}
+	return parseUint(s, 10, 64)
+}
+
+// psi is the pressure stall information in percent, like memory.pressure:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+type psi struct {
+	SomeAvg10 float64
+	FullAvg10 float64
+}
+
+func readPSI(path string) (psi, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return psi{}, err
+	}
+	defer f.Close() // nolint: errcheck
+
+	return parsePSI(f)
+}
+
+func parsePSI(r io.Reader) (psi, error) {
+	var p psi
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "avg10=") {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimPrefix(fields[1], "avg10="), 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid %v avg10: %w", fields[0], err)
+		}
+		switch fields[0] {
+		case "some":
+			p.SomeAvg10 = v
+		case "full":
+			p.FullAvg10 = v
+		}
+	}
+	return p, scanner.Err()
+}
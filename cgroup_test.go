@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFlatKeyed(t *testing.T) {
+	kv, err := parseFlatKeyed(strings.NewReader("low 0\nhigh 3\nmax 1\noom 0\noom_kill 0\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]uint64{"low": 0, "high": 3, "max": 1, "oom": 0, "oom_kill": 0}, kv)
+
+	_, err = parseFlatKeyed(strings.NewReader("high x\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParsePSI(t *testing.T) {
+	p, err := parsePSI(strings.NewReader("some avg10=12.50 avg60=3.00 avg300=0.00 total=100\nfull avg10=1.25 avg60=0.00 avg300=0.00 total=10\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, psi{SomeAvg10: 12.5, FullAvg10: 1.25}, p)
+}
+
+func TestCGroupMemStats(t *testing.T) {
+	stats := cgroupMemStats{Current: 900, Max: 1000, InactiveFile: 400}
+	assert.Equal(t, uint64(500), stats.workingSet())
+	assert.Equal(t, 50, stats.workingSetPercent())
+
+	stats.InactiveFile = 1000
+	assert.Equal(t, 0, stats.workingSetPercent())
+
+	prev := memEvents{High: 1}
+	assert.False(t, memEvents{High: 1}.increasedSince(prev))
+	assert.True(t, memEvents{High: 1, OOMKill: 1}.increasedSince(prev))
+}
+
+func TestCPUThrottlingStats(t *testing.T) {
+	prev := CPUThrottlingStats{Periods: 100, Throttled: 10, ThrottledTime: time.Second}
+	cur := CPUThrottlingStats{Periods: 150, Throttled: 30, ThrottledTime: 3 * time.Second}
+
+	delta := cur.sub(prev)
+	assert.Equal(t, CPUThrottlingStats{Periods: 50, Throttled: 20, ThrottledTime: 2 * time.Second}, delta)
+	assert.Equal(t, 40, delta.throttledPercent())
+
+	// the counters are reset
+	assert.Equal(t, 0, prev.sub(cur).throttledPercent())
+}
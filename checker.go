@@ -0,0 +1,348 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// Checker is a check run in each cycle of the dump loop, holmes keeps the recent values collected by it,
+// and dumps by it when the current value matches the min/abs/diff rules of its options, then skips it in cooldown.
+// the built-in checks of mem, cpu, thread, goroutine, alloc, fd, mempressure and cputhrottle are Checkers too.
+type Checker interface {
+	// Name returns the name of the check, it's unique in holmes,
+	// and used as the check name in the logs, the event log, the event ID and CheckStatus.
+	Name() string
+	// Collect returns the current value of the check, it's called in each cycle even the check is disabled,
+	// false means the value is not available in this cycle.
+	Collect() (int, bool)
+	// Ring returns the number of the recent values to calculate the average for the diff rule.
+	Ring() int
+	// Options returns the trigger options of the check, it's skipped when it's disabled.
+	Options() CheckOptions
+	// Dump writes the profiles of the triggered check by DumpContext.WriteProfile,
+	// the check goes into cooldown only when it returns nil.
+	Dump(d *DumpContext) error
+}
+
+// CheckOptions is the trigger options of a Checker, it dumps when value > TriggerAbs,
+// or value > TriggerMin and the diff percent to the average > TriggerDiff,
+// and skips dumping when value >= TriggerMax, 0 means no max.
+type CheckOptions struct {
+	typeOption
+	TriggerMax int
+}
+
+// NewCheckOptions returns the enabled CheckOptions.
+func NewCheckOptions(min int, diff int, abs int, coolDown time.Duration) CheckOptions {
+	o := CheckOptions{typeOption: *newTypeOpts(min, abs, diff, coolDown)}
+	o.Enable = true
+	return o
+}
+
+// DumpContext is the triggered check passed to Checker.Dump,
+// the profiles written by it are reported with the reason and the scene of the check, and recorded in the event log.
+type DumpContext struct {
+	h   *Holmes
+	ctx context.Context
+	// Check is the name of the triggered check.
+	Check string
+	// EventID is shared by the profiles of the dump, e.g. "queue-0", it may be empty for the built-in checks.
+	EventID string
+	Reason  ReasonType
+	Scene   Scene
+
+	stats ring
+	files []string
+	// flush the written profiles to disk.
+	fsync bool
+}
+
+// the profiles could be written by DumpContext.WriteProfile, to the check type of the dump file.
+var profile2type = map[string]configureType{
+	"heap":         mem,
+	"cpu":          cpu,
+	"threadcreate": thread,
+	"goroutine":    goroutine,
+	"allocs":       allocRate,
+}
+
+// WriteProfile writes the profile to the dump path and reports it, returns the file name.
+// the profile is one of heap, cpu, threadcreate, goroutine and allocs, the cpu profile is sampled for CPUSamplingTime.
+func (d *DumpContext) WriteProfile(profile string) (string, error) {
+	typ, ok := profile2type[profile]
+	if !ok {
+		return "", fmt.Errorf("unsupported profile %v", profile)
+	}
+
+	h := d.h
+	if typ == cpu {
+		fileName, data, err := h.writeCPUProfileToFile(d.ctx, d.EventID, h.opts.CPUSamplingTime)
+		if err != nil {
+			return fileName, err
+		}
+		d.report(type2name[cpu], fileName, data)
+		return fileName, nil
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup(profile).WriteTo(&buf, h.opts.profileDebug(typ)); err != nil {
+		return "", fmt.Errorf("pprof %v failed: %w", profile, err)
+	}
+	fileName, data := h.writeProfileData(buf, typ, d.EventID, d.fsync)
+	if fileName == "" {
+		return "", fmt.Errorf("write %v profile failed", profile)
+	}
+	d.report(profile, fileName, data)
+	return fileName, nil
+}
+
+// report reports the dumped file, and records it in the event log.
+func (d *DumpContext) report(pType string, fileName string, data []byte) {
+	d.h.ReportProfile(pType, fileName, d.Reason, d.EventID, d.h.now(), data, d.Scene)
+	d.addFiles(fileName)
+}
+
+func (d *DumpContext) addFiles(files ...string) {
+	d.files = append(d.files, files...)
+}
+
+// RegisterChecker registers the checker to run in each cycle of the dump loop after the built-in ones,
+// the name must be unique and not contain ".", since it's a part of the dump file names.
+func (h *Holmes) RegisterChecker(c Checker) error {
+	name := c.Name()
+	if name == "" || strings.ContainsAny(name, `./\`) {
+		return fmt.Errorf("invalid checker name %q", name)
+	}
+	for _, check := range check2name {
+		if name == check {
+			return fmt.Errorf("checker %v is a built-in check", name)
+		}
+	}
+
+	size := c.Ring()
+	if size <= 0 {
+		size = minCollectCyclesBeforeDumpStart
+	}
+
+	h.checkersMu.Lock()
+	defer h.checkersMu.Unlock()
+	if _, ok := h.checkStates[name]; ok {
+		return fmt.Errorf("checker %v is registered already", name)
+	}
+	h.checkStates[name] = newCheckState(size)
+	h.checkers = append(h.checkers, c)
+	return nil
+}
+
+// getCheckers returns the registered checkers.
+func (h *Holmes) getCheckers() []Checker {
+	h.checkersMu.RLock()
+	defer h.checkersMu.RUnlock()
+	return h.checkers
+}
+
+// checkAndDump checks the current value by the options of the checker, and dumps by it when triggered.
+func (h *Holmes) checkAndDump(c Checker, cur int) {
+	// get a copy instead of locking it
+	opts := c.Options()
+	if !opts.Enable {
+		return
+	}
+
+	name := c.Name()
+	state := h.stateOf(name)
+	if until := state.coolDownUntil(); until.After(h.now()) {
+		h.logCoolDown(name, cur, until)
+		return
+	}
+
+	stats := state.history()
+	b, isBuiltin := c.(builtin)
+	var match bool
+	var reason ReasonType
+	if isBuiltin {
+		match, reason = b.match(stats, cur, opts)
+	} else {
+		match, reason = matchRule(stats, cur, opts.TriggerMin, opts.TriggerAbs, opts.TriggerDiff, opts.TriggerMax)
+	}
+	if !match {
+		// let user know why this should not dump
+		h.logNoDump(name, opts.typeOption, opts.TriggerMax, stats, cur, reason)
+		return
+	}
+
+	d := &DumpContext{
+		h:      h,
+		ctx:    h.runContext(),
+		Check:  name,
+		Reason: reason,
+		Scene: Scene{
+			typeOption: opts.typeOption,
+			Check:      name,
+			CurVal:     cur,
+			Avg:        stats.avg(),
+		},
+		stats: stats,
+	}
+	if isBuiltin {
+		// the built-in checks alert with the hint of their profiles.
+		d.EventID = b.eventID(state.count())
+	} else {
+		d.EventID = fmt.Sprintf("%s-%d", name, state.count())
+		h.alertCheck("holmes."+name, name, reason, d.EventID, UniformLogFormat, "pprof", name,
+			opts.TriggerMin, opts.TriggerDiff, opts.TriggerAbs, opts.TriggerMax, stats.sequentialData(), cur)
+	}
+
+	if err := c.Dump(d); err != nil {
+		h.Errorf("[Holmes] failed to dump %v: %v", name, err)
+		h.logDumpFailed(name, opts.typeOption, opts.TriggerMax, stats, cur, reason, d.EventID, err)
+		return
+	}
+
+	h.logDump(name, opts.typeOption, opts.TriggerMax, stats, cur, reason, opts.CoolDown, d.EventID, d.files...)
+	now := h.now()
+	state.triggered(now, now.Add(opts.CoolDown))
+}
+
+// builtin is implemented by the built-in checkers, to keep their own rules and event IDs.
+type builtin interface {
+	match(stats ring, cur int, opts CheckOptions) (bool, ReasonType)
+	eventID(count int) string
+}
+
+// builtinChecker is a built-in check, the value is collected by the dump loop in the cycle.
+type builtinChecker struct {
+	typ configureType
+	// the prefix of the event ID, empty means the dumps are not in an event.
+	eventPrefix string
+	collect     func() (int, bool)
+	options     func() CheckOptions
+	// the extra rules besides the min/abs/diff rules, could be nil.
+	matchMore func() (bool, ReasonType)
+	dump      func(d *DumpContext) error
+}
+
+func (c *builtinChecker) Name() string {
+	return check2name[c.typ]
+}
+
+func (c *builtinChecker) Collect() (int, bool) {
+	return c.collect()
+}
+
+func (c *builtinChecker) Ring() int {
+	return minCollectCyclesBeforeDumpStart
+}
+
+func (c *builtinChecker) Options() CheckOptions {
+	return c.options()
+}
+
+func (c *builtinChecker) Dump(d *DumpContext) error {
+	return c.dump(d)
+}
+
+func (c *builtinChecker) match(stats ring, cur int, opts CheckOptions) (bool, ReasonType) {
+	match, reason := matchRule(stats, cur, opts.TriggerMin, opts.TriggerAbs, opts.TriggerDiff, opts.TriggerMax)
+	if !match && c.matchMore != nil {
+		if more, moreReason := c.matchMore(); more {
+			return true, moreReason
+		}
+	}
+	return match, reason
+}
+
+func (c *builtinChecker) eventID(count int) string {
+	if c.eventPrefix == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s-%d", c.eventPrefix, count)
+}
+
+// newBuiltinCheckers returns the built-in checkers in the order of checking,
+// the thread check is before the goroutine check, since the thread dump cools down the goroutine check.
+func (h *Holmes) newBuiltinCheckers() []Checker {
+	return []Checker{
+		&builtinChecker{
+			typ:     mem,
+			collect: func() (int, bool) { return h.cycle.Mem, true },
+			options: func() CheckOptions { return CheckOptions{typeOption: h.opts.GetMemOpts()} },
+			dump:    h.memDump,
+		},
+		&builtinChecker{
+			typ:     cpu,
+			collect: func() (int, bool) { return h.cycle.CPU, true },
+			options: func() CheckOptions { return CheckOptions{typeOption: h.opts.GetCPUOpts()} },
+			dump:    h.cpuDump,
+		},
+		&builtinChecker{
+			typ:         thread,
+			eventPrefix: "thr",
+			collect:     func() (int, bool) { return h.cycle.Thread, true },
+			options:     func() CheckOptions { return CheckOptions{typeOption: h.opts.GetThreadOpts()} },
+			dump:        h.threadDump,
+		},
+		&builtinChecker{
+			typ:     goroutine,
+			collect: func() (int, bool) { return h.cycle.Goroutine, true },
+			options: func() CheckOptions {
+				grOpts := h.opts.GetGrOpts()
+				return CheckOptions{typeOption: *grOpts.typeOption, TriggerMax: grOpts.GoroutineTriggerNumMax}
+			},
+			dump: h.goroutineDump,
+		},
+		&builtinChecker{
+			typ:         allocRate,
+			eventPrefix: "alloc",
+			collect:     func() (int, bool) { return h.cycle.allocRate, true },
+			options:     func() CheckOptions { return CheckOptions{typeOption: *h.opts.GetAllocOpts().typeOption} },
+			dump:        h.allocRateDump,
+		},
+		&builtinChecker{
+			typ:         fd,
+			eventPrefix: "fd",
+			collect:     func() (int, bool) { return h.cycle.fd.usage, h.cycle.fdCollected },
+			options:     func() CheckOptions { return CheckOptions{typeOption: *h.opts.GetFDOpts().typeOption} },
+			matchMore:   h.fdNumMatch,
+			dump:        h.fdDump,
+		},
+		&builtinChecker{
+			typ:         memPressure,
+			eventPrefix: "mempressure",
+			collect:     func() (int, bool) { return h.cycle.memPressure.usage, h.cycle.memPressureCollected },
+			options:     func() CheckOptions { return CheckOptions{typeOption: *h.opts.GetMemPressureOpts().typeOption} },
+			matchMore:   h.memPressureMatch,
+			dump:        h.memPressureDump,
+		},
+		&builtinChecker{
+			typ:         cpuThrottle,
+			eventPrefix: "throttle",
+			collect: func() (int, bool) {
+				return h.cycle.throttling.throttledPercent(), h.cycle.throttlingCollected
+			},
+			options: func() CheckOptions { return CheckOptions{typeOption: h.opts.GetCPUThrottleOpts()} },
+			dump:    h.cpuThrottleDump,
+		},
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testChecker struct {
+	name string
+}
+
+func (c testChecker) Name() string {
+	return c.name
+}
+
+func (c testChecker) Collect() (int, bool) {
+	return 0, false
+}
+
+func (c testChecker) Ring() int {
+	return 0
+}
+
+func (c testChecker) Options() CheckOptions {
+	return NewCheckOptions(10, 25, 80, time.Minute)
+}
+
+func (c testChecker) Dump(d *DumpContext) error {
+	return nil
+}
+
+func TestRegisterChecker(t *testing.T) {
+	h, err := New()
+	assert.Nil(t, err)
+
+	assert.NotNil(t, h.RegisterChecker(testChecker{}))
+	assert.NotNil(t, h.RegisterChecker(testChecker{name: "queue.len"}))
+	assert.NotNil(t, h.RegisterChecker(testChecker{name: "mem"}))
+	assert.NotNil(t, h.RegisterChecker(testChecker{name: "GCHeap"}))
+
+	assert.Nil(t, h.RegisterChecker(testChecker{name: "queue"}))
+	assert.NotNil(t, h.RegisterChecker(testChecker{name: "queue"}))
+
+	status := h.CheckStatus()
+	assert.Equal(t, "queue", status[8].Check)
+	assert.Equal(t, minCollectCyclesBeforeDumpStart, h.stateOf("queue").stats.maxLen)
+}
+
+func TestDumpContextWriteProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-checker")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	h, err := New(WithDumpPath(dir))
+	assert.Nil(t, err)
+
+	d := &DumpContext{h: h, ctx: context.Background(), Check: "queue", EventID: "queue-0"}
+	_, err = d.WriteProfile("block")
+	assert.NotNil(t, err)
+
+	fileName, err := d.WriteProfile("heap")
+	assert.Nil(t, err)
+	assert.FileExists(t, fileName)
+	assert.Contains(t, fileName, "mem.queue-0.")
+	assert.Equal(t, []string{fileName}, d.files)
+}
@@ -0,0 +1,185 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"sync"
+	"time"
+)
+
+// checkState is the state of a check, it's written by the dump loop, the GC heap check loop
+// or the thread shrinking, and could be read concurrently by CheckStatus, so it has its own lock.
+type checkState struct {
+	mu sync.RWMutex
+	// the values collected in the recent cycles.
+	stats ring
+	// skip the check until the cooldown time.
+	coolDown time.Time
+	// the number of dumps, it's a part of the event ID.
+	triggerCount int
+	// the time of the latest dump.
+	lastDump time.Time
+}
+
+// newCheckState returns the state which keeps size recent values.
+func newCheckState(size int) *checkState {
+	return &checkState{
+		stats: newRing(size),
+	}
+}
+
+// reset clears the collected values and sets the cooldown time on start, the trigger count is kept.
+func (s *checkState) reset(coolDown time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = newRing(s.stats.maxLen)
+	s.coolDown = coolDown
+}
+
+func (s *checkState) push(v int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.push(v)
+}
+
+// history returns a copy of the collected values.
+func (s *checkState) history() ring {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r := s.stats
+	r.data = append(make([]int, 0, r.maxLen), r.data...)
+	return r
+}
+
+// coolDownUntil returns the time the check starts again.
+func (s *checkState) coolDownUntil() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.coolDown
+}
+
+// setCoolDown skips the check until the cooldown time, without a dump.
+func (s *checkState) setCoolDown(coolDown time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coolDown = coolDown
+}
+
+// triggered records a dump at now, and skips the check until the cooldown time.
+func (s *checkState) triggered(now, coolDown time.Time) {
+	s.next(now, coolDown)
+}
+
+// next records a dump at now like triggered, and returns the trigger count before it as the sequence
+// of the event ID, so the concurrent dumps get different event IDs.
+func (s *checkState) next(now, coolDown time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq := s.triggerCount
+	s.coolDown = coolDown
+	s.triggerCount++
+	s.lastDump = now
+	return seq
+}
+
+// count returns the number of dumps.
+func (s *checkState) count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.triggerCount
+}
+
+// CheckStatus is the state of a check.
+type CheckStatus struct {
+	Check string
+	// the values collected in the recent cycles, from the oldest to the latest, and the average of them.
+	History []int
+	Avg     int
+	// the check is skipped until the time.
+	CoolDownUntil time.Time
+	// the number of dumps and the time of the latest one.
+	TriggerCount int
+	LastDump     time.Time
+}
+
+func (s *checkState) status(check string) CheckStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return CheckStatus{
+		Check:         check,
+		History:       s.stats.sequentialData(),
+		Avg:           s.stats.avg(),
+		CoolDownUntil: s.coolDown,
+		TriggerCount:  s.triggerCount,
+		LastDump:      s.lastDump,
+	}
+}
+
+// the check types with state besides the checkers, they are checked on GC, in their own intervals or on demand.
+var stateCheckTypes = []configureType{gcHeap, goroutineLeak, manualDump, signalDump, criticalDump, panicDump}
+
+// newCheckStates returns the states of the built-in checks.
+func newCheckStates(checkers []Checker) map[string]*checkState {
+	states := make(map[string]*checkState, len(checkers)+len(stateCheckTypes))
+	for _, c := range checkers {
+		states[c.Name()] = newCheckState(c.Ring())
+	}
+	for _, typ := range stateCheckTypes {
+		states[check2name[typ]] = newCheckState(minCollectCyclesBeforeDumpStart)
+	}
+	return states
+}
+
+// state returns the state of the built-in check type.
+func (h *Holmes) state(typ configureType) *checkState {
+	return h.stateOf(check2name[typ])
+}
+
+// stateOf returns the state of the check name, the state is never removed after it's registered.
+func (h *Holmes) stateOf(name string) *checkState {
+	h.checkersMu.RLock()
+	defer h.checkersMu.RUnlock()
+	return h.checkStates[name]
+}
+
+// resetCheckStates resets the states of the checks except the GC heap one on start,
+// which is reset by the GC heap check loop.
+func (h *Holmes) resetCheckStates(coolDown time.Time) {
+	h.checkersMu.RLock()
+	defer h.checkersMu.RUnlock()
+	for name, state := range h.checkStates {
+		if name != check2name[gcHeap] {
+			state.reset(coolDown)
+		}
+	}
+}
+
+// CheckStatus returns the state of the checks, the checkers in the order of registration, then the GC heap,
+// the goroutine leak, the manual, signal, critical and panic dumps, and the thread shrinking,
+// it's safe to call while holmes is running.
+func (h *Holmes) CheckStatus() []CheckStatus {
+	checkers := h.getCheckers()
+	status := make([]CheckStatus, 0, len(checkers)+len(stateCheckTypes)+1)
+	for _, c := range checkers {
+		status = append(status, h.stateOf(c.Name()).status(c.Name()))
+	}
+	for _, typ := range stateCheckTypes {
+		status = append(status, h.state(typ).status(check2name[typ]))
+	}
+	return append(status, h.shrinkState.status("shrinkthread"))
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import "time"
+
+// Clock is the time source of the dump loop, the cooldowns and the reports,
+// it could be replaced by WithClock to control the time in tests, see holmestest.Clock.
+type Clock interface {
+	Now() time.Time
+	// NewTicker returns a ticker which delivers the ticks every d.
+	NewTicker(d time.Duration) Ticker
+	// NewTimer returns a timer which fires once after d, e.g. for the cpu sampling and the delay of the thread shrink.
+	NewTimer(d time.Duration) Timer
+}
+
+// Ticker delivers the ticks of a Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer fires once on the time of a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, returns false if it has already fired or been stopped.
+	Stop() bool
+}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// now returns the current time of the clock.
+func (h *Holmes) now() time.Time {
+	return h.opts.GetClock().Now()
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"mosn.io/holmes/internal/inspect"
+)
+
+func runSummarize(args []string) error {
+	fs := flag.NewFlagSet("summarize", flag.ExitOnError)
+	n := fs.Int("n", 10, "the number of top goroutine groups")
+	fs.Parse(args) // nolint: errcheck
+	if fs.NArg() != 1 {
+		return errors.New("usage: holmes summarize [flags] <goroutine dump>")
+	}
+
+	data, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	summary, err := inspect.SummarizeGoroutines(data, *n)
+	if err != nil {
+		return fmt.Errorf("%v is not a goroutine dump: %w", fs.Arg(0), err)
+	}
+	fmt.Print(string(summary))
+	return nil
+}
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	n := fs.Int("n", 10, "the number of top growing functions")
+	sample := fs.String("sample", "", "the sample type to rank the functions, e.g. inuse_space, alloc_space")
+	out := fs.String("o", "", "write the delta profile to the file, which could be opened by go tool pprof")
+	fs.Parse(args) // nolint: errcheck
+	if fs.NArg() != 2 {
+		return errors.New("usage: holmes diff [flags] <base> <cur>")
+	}
+
+	base, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cur, err := ioutil.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diff, top, err := inspect.DiffProfiles(base, cur, *sample, *n)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(top))
+
+	if *out != "" {
+		if err := ioutil.WriteFile(*out, diff, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("\ndelta profile is written to %s\n", *out)
+	}
+	return nil
+}
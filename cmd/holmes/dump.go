@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dumpTimeLayout is the time layout in the dump file name.
+const dumpTimeLayout = "20060102150405.000"
+
+// the profile type of the dump file prefix, the others are text files, e.g. fd, goroutinesummary.
+var prefix2profile = map[string]string{
+	"mem":           "heap",
+	"GCHeap":        "heap",
+	"heapdiff":      "heap",
+	"cpu":           "cpu",
+	"cpudiff":       "cpu",
+	"thread":        "threadcreate",
+	"goroutine":     "goroutine",
+	"goroutinediff": "goroutine",
+	"alloc":         "allocs",
+}
+
+// dumpFile is a dump in the dump directory, which is named as
+// "<type>.<time>.log" or "<type>.<event ID>.<time>.log", e.g. "GCHeap.heap-1.20220101120000.000.log".
+type dumpFile struct {
+	Path string
+	// the check type prefix, e.g. mem, GCHeap, goroutinesummary.
+	Type string
+	// empty when the dump isn't in an event.
+	EventID string
+	Time    time.Time
+	Size    int64
+}
+
+// event returns the event the dump belongs to, the diffs like "heap-1.baseline" belong to the event "heap-1".
+func (d dumpFile) event() string {
+	return strings.SplitN(d.EventID, ".", 2)[0]
+}
+
+// profileType returns the pprof profile type of the dump, empty for the text files.
+func (d dumpFile) profileType() string {
+	return prefix2profile[d.Type]
+}
+
+// parseDumpFileName parses the dump file name, returns false when it isn't a dump.
+func parseDumpFileName(name string) (dumpFile, bool) {
+	var d dumpFile
+	if !strings.HasSuffix(name, ".log") {
+		return d, false
+	}
+	parts := strings.Split(strings.TrimSuffix(name, ".log"), ".")
+	if len(parts) < 3 {
+		return d, false
+	}
+
+	n := len(parts)
+	t, err := time.ParseInLocation(dumpTimeLayout, parts[n-2]+"."+parts[n-1], time.Local)
+	if err != nil {
+		return d, false
+	}
+	d.Type = parts[0]
+	d.EventID = strings.Join(parts[1:n-2], ".")
+	d.Time = t
+	return d, true
+}
+
+// scanDumps returns the dumps in the dir sorted by time.
+func scanDumps(dir string) ([]dumpFile, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dumps []dumpFile
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		d, ok := parseDumpFileName(info.Name())
+		if !ok {
+			continue
+		}
+		d.Path = filepath.Join(dir, info.Name())
+		d.Size = info.Size()
+		dumps = append(dumps, d)
+	}
+	sort.SliceStable(dumps, func(i, j int) bool {
+		return dumps[i].Time.Before(dumps[j].Time)
+	})
+	return dumps, nil
+}
+
+// statDump returns the dump of the path, the file name is not required to be a dump.
+func statDump(path string) (dumpFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return dumpFile{}, err
+	}
+	d, _ := parseDumpFileName(filepath.Base(path))
+	d.Path = path
+	d.Size = info.Size()
+	return d, nil
+}
+
+// formatSize formats the size in bytes in human readable.
+func formatSize(size int64) string {
+	f := float64(size)
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.2fGB", f/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.2fMB", f/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.2fkB", f/(1<<10))
+	}
+	return fmt.Sprintf("%dB", size)
+}
@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDumpFileName(t *testing.T) {
+	d, ok := parseDumpFileName("goroutine.20220101120000.123.log")
+	assert.True(t, ok)
+	assert.Equal(t, "goroutine", d.Type)
+	assert.Equal(t, "", d.EventID)
+	assert.Equal(t, "", d.event())
+	assert.Equal(t, "goroutine", d.profileType())
+	assert.Equal(t, time.Date(2022, 1, 1, 12, 0, 0, 123e6, time.Local), d.Time)
+
+	d, ok = parseDumpFileName("heapdiff.heap-1.baseline.20220101120000.123.log")
+	assert.True(t, ok)
+	assert.Equal(t, "heapdiff", d.Type)
+	assert.Equal(t, "heap-1.baseline", d.EventID)
+	assert.Equal(t, "heap-1", d.event())
+	assert.Equal(t, "heap", d.profileType())
+
+	d, ok = parseDumpFileName("fd.fd-0.20220101120000.123.log")
+	assert.True(t, ok)
+	assert.Equal(t, "", d.profileType())
+
+	for _, name := range []string{"holmes.log", "goroutine.log", "goroutine.2022.log", "cpu.20220101120000.123.pprof"} {
+		_, ok = parseDumpFileName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestSelectPrune(t *testing.T) {
+	now := time.Now()
+	dumps := []dumpFile{
+		{Path: "a", Time: now.Add(-3 * time.Hour), Size: 100},
+		{Path: "b", Time: now.Add(-2 * time.Hour), Size: 100},
+		{Path: "c", Time: now.Add(-time.Hour), Size: 100},
+	}
+
+	paths := func(dumps []dumpFile) []string {
+		var ps []string
+		for _, d := range dumps {
+			ps = append(ps, d.Path)
+		}
+		return ps
+	}
+
+	assert.Nil(t, selectPrune(dumps, now, 0, 0))
+	assert.Equal(t, []string{"a"}, paths(selectPrune(dumps, now, 150*time.Minute, 0)))
+	assert.Equal(t, []string{"a", "b"}, paths(selectPrune(dumps, now, 0, 150)))
+	assert.Equal(t, []string{"a", "b"}, paths(selectPrune(dumps, now, 90*time.Minute, 300)))
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags("region=us-east,host=a=b")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"region": "us-east", "host": "a=b"}, tags)
+
+	tags, err = parseTags("")
+	assert.Nil(t, err)
+	assert.Empty(t, tags)
+
+	_, err = parseTags("region")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/pprof/profile"
+
+	"mosn.io/holmes/internal/inspect"
+)
+
+const timeLayout = "2006-01-02 15:04:05.000"
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := fs.String("dir", "/tmp", "the dump directory")
+	typ := fs.String("type", "", "only list the dumps of the type, e.g. goroutine, GCHeap")
+	event := fs.String("event", "", "only list the dumps of the event ID")
+	fs.Parse(args) // nolint: errcheck
+
+	dumps, err := scanDumps(*dir)
+	if err != nil {
+		return err
+	}
+
+	// group by event, in the order of the first dump of each event.
+	var events []string
+	groups := make(map[string][]dumpFile)
+	for _, d := range dumps {
+		if *typ != "" && d.Type != *typ {
+			continue
+		}
+		if *event != "" && d.event() != *event {
+			continue
+		}
+		e := d.event()
+		if _, ok := groups[e]; !ok {
+			events = append(events, e)
+		}
+		groups[e] = append(groups[e], d)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "EVENT\tTYPE\tTIME\tSIZE\tFILE")
+	for _, e := range events {
+		name := e
+		if name == "" {
+			name = "-"
+		}
+		for _, d := range groups[e] {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, d.Type, d.Time.Format(timeLayout), formatSize(d.Size), d.Path)
+		}
+	}
+	return w.Flush()
+}
+
+func runShow(args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	n := fs.Int("n", 10, "the number of top functions, or the lines of a text dump")
+	sample := fs.String("sample", "", "the sample type to rank the functions, e.g. inuse_space, alloc_space")
+	cum := fs.Bool("cum", false, "rank the functions by the cumulative value instead of flat")
+	fs.Parse(args) // nolint: errcheck
+	if fs.NArg() != 1 {
+		return errors.New("usage: holmes show [flags] <file>")
+	}
+
+	d, err := statDump(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(d.Path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("file:    %s\n", d.Path)
+	if d.Type != "" {
+		fmt.Printf("type:    %s\n", d.Type)
+	}
+	if d.EventID != "" {
+		fmt.Printf("event:   %s\n", d.EventID)
+	}
+	if !d.Time.IsZero() {
+		fmt.Printf("time:    %s\n", d.Time.Format(timeLayout))
+	}
+	fmt.Printf("size:    %s\n", formatSize(d.Size))
+
+	p, err := profile.ParseData(data)
+	if err != nil || len(p.SampleType) == 0 || (!inspect.IsBinaryProfile(data) && len(p.Function) == 0) {
+		// not a profile, e.g. the fd list, the top n report or the legacy text profile in text mode.
+		fmt.Printf("format:  text\n\n")
+		return printLines(data, *n)
+	}
+
+	fmt.Printf("format:  profile\n")
+	var types []string
+	for _, st := range p.SampleType {
+		types = append(types, st.Type+"/"+st.Unit)
+	}
+	fmt.Printf("samples: %d %v\n", len(p.Sample), types)
+	if p.DurationNanos > 0 {
+		fmt.Printf("duration: %v\n", time.Duration(p.DurationNanos))
+	}
+
+	sortBy := inspect.SortByFlat
+	if *cum {
+		sortBy = inspect.SortByCum
+	}
+	top, err := inspect.ProfileTop(data, *sample, *n, sortBy)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n%s", top)
+	return nil
+}
+
+// printLines prints the first n lines of the data.
+func printLines(data []byte, n int) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for i := 0; i < n && scanner.Scan(); i++ {
+		fmt.Println(scanner.Text())
+	}
+	return scanner.Err()
+}
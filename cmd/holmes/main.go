@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command holmes inspects the dump directory of holmes.
+//
+// Usage:
+//
+//	holmes list [-dir /tmp] [-type goroutine] [-event heap-1]
+//	holmes show [-n 10] [-sample inuse_space] [-cum] <file>
+//	holmes summarize [-n 10] <goroutine dump>
+//	holmes diff [-n 10] [-sample inuse_space] [-o diff.pb.gz] <base> <cur>
+//	holmes prune [-dir /tmp] [-age 168h] [-max-size 1024] [-dry-run]
+//	holmes resend -reporter http|pyroscope [reporter flags] <file>...
+//	holmes simulate [-types cpu,mem] [-cpu 10,25,80,1m] [rule flags] <samples>
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"list", "list the dumps grouped by event ID and type", runList},
+	{"show", "show the metadata and top functions of a dump", runShow},
+	{"summarize", "summarize a goroutine dump by identical stacks", runSummarize},
+	{"diff", "diff two dumps of the same profile type", runDiff},
+	{"prune", "remove the dumps by age and total size", runPrune},
+	{"resend", "send stored dumps through a bundled reporter", runResend},
+	{"simulate", "replay recorded samples to tune the trigger rules", runSimulate},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: holmes <command> [flags] [args]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'holmes <command> -h' for the flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if err := c.run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "holmes %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "holmes: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+func runPrune(args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	dir := fs.String("dir", "/tmp", "the dump directory")
+	age := fs.Duration("age", 0, "remove the dumps older than the age, 0 means no limit")
+	maxSize := fs.Int64("max-size", 0, "remove the oldest dumps until the total size is at most max-size MB, 0 means no limit")
+	dryRun := fs.Bool("dry-run", false, "only print the dumps to remove")
+	fs.Parse(args) // nolint: errcheck
+
+	dumps, err := scanDumps(*dir)
+	if err != nil {
+		return err
+	}
+
+	var removed, freed int64
+	for _, d := range selectPrune(dumps, time.Now(), *age, *maxSize<<20) {
+		if !*dryRun {
+			if err := os.Remove(d.Path); err != nil {
+				return err
+			}
+		}
+		fmt.Println(d.Path)
+		removed++
+		freed += d.Size
+	}
+
+	verb := "removed"
+	if *dryRun {
+		verb = "would remove"
+	}
+	fmt.Printf("%s %d dumps, %s\n", verb, removed, formatSize(freed))
+	return nil
+}
+
+// selectPrune returns the dumps to remove, the dumps must be sorted by time.
+// the dumps older than age are removed, then the oldest ones until the total size is at most maxSize.
+func selectPrune(dumps []dumpFile, now time.Time, age time.Duration, maxSize int64) []dumpFile {
+	var total int64
+	for _, d := range dumps {
+		total += d.Size
+	}
+
+	var pruned []dumpFile
+	for _, d := range dumps {
+		expired := age > 0 && now.Sub(d.Time) > age
+		oversize := maxSize > 0 && total > maxSize
+		if !expired && !oversize {
+			break
+		}
+		pruned = append(pruned, d)
+		total -= d.Size
+	}
+	return pruned
+}
@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"mosn.io/holmes"
+	"mosn.io/holmes/mosnlog"
+	"mosn.io/holmes/reporters/http_reporter"
+	"mosn.io/holmes/reporters/pyroscope_reporter"
+)
+
+func runResend(args []string) error {
+	fs := flag.NewFlagSet("resend", flag.ExitOnError)
+	reporter := fs.String("reporter", "", "the reporter to send the dumps, http or pyroscope")
+	url := fs.String("url", "", "http: the url to upload the dumps; pyroscope: the server address, e.g. http://localhost:4040")
+	token := fs.String("token", "", "http: the token of the dump server")
+	app := fs.String("app", "holmes-client", "pyroscope: the application name")
+	tags := fs.String("tags", "", "pyroscope: the tags of the application, e.g. region=us-east,host=a")
+	timeout := fs.Duration("timeout", 3*time.Second, "pyroscope: the upload request timeout")
+	fs.Parse(args) // nolint: errcheck
+	if fs.NArg() == 0 || *url == "" {
+		return errors.New("usage: holmes resend -reporter http|pyroscope -url <url> [flags] <file>...")
+	}
+
+	var r holmes.ProfileReporter
+	switch *reporter {
+	case "http":
+		r = http_reporter.NewReporter(*token, *url)
+	case "pyroscope":
+		tagMap, err := parseTags(*tags)
+		if err != nil {
+			return err
+		}
+		cfg := pyroscope_reporter.RemoteConfig{
+			UpstreamAddress:        *url,
+			UpstreamRequestTimeout: *timeout,
+		}
+		pr, err := pyroscope_reporter.NewPyroscopeReporter(*app, tagMap, cfg, mosnlog.NewStdLogger())
+		if err != nil {
+			return err
+		}
+		r = pr
+	default:
+		return fmt.Errorf("unknown reporter %q, http or pyroscope", *reporter)
+	}
+
+	for _, path := range fs.Args() {
+		if err := resend(r, path); err != nil {
+			return fmt.Errorf("resend %v failed: %w", path, err)
+		}
+		fmt.Printf("%s sent\n", path)
+	}
+	return nil
+}
+
+func resend(r holmes.ProfileReporter, path string) error {
+	d, err := statDump(path)
+	if err != nil {
+		return err
+	}
+	ptype := d.profileType()
+	if ptype == "" {
+		return errors.New("not a profile dump")
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	t := d.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return r.Report(ptype, path, holmes.ReasonResend, d.EventID, t, data, holmes.Scene{})
+}
+
+// parseTags parses the tags like "region=us-east,host=a".
+func parseTags(s string) (map[string]string, error) {
+	tags := make(map[string]string)
+	if s == "" {
+		return tags, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid tag %q, must be key=value", kv)
+		}
+		tags[parts[0]] = parts[1]
+	}
+	return tags, nil
+}
@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mosn.io/holmes"
+)
+
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	types := fs.String("types", "", "the check types to simulate, e.g. cpu,goroutine, all of cpu, mem, goroutine and thread by default")
+	interval := fs.String("interval", "", "the collect interval, only used when the samples have no time, default 5s")
+	cpuMax := fs.Int("cpu-max", 0, "skip all dumps when cpu usage >= cpu-max, 0 means no limit")
+	cpuRule := fs.String("cpu", "", "the cpu rule: min,diff,abs,cooldown, e.g. 10,25,80,1m")
+	memRule := fs.String("mem", "", "the mem rule: min,diff,abs,cooldown, e.g. 10,25,80,1m")
+	grRule := fs.String("goroutine", "", "the goroutine rule: min,diff,abs,max,cooldown, e.g. 3000,20,200000,0,10m")
+	threadRule := fs.String("thread", "", "the thread rule: min,diff,abs,cooldown, e.g. 10,25,70,1h")
+	fs.Parse(args) // nolint: errcheck
+	if fs.NArg() != 1 {
+		return errors.New("usage: holmes simulate [flags] <samples in csv or json>")
+	}
+
+	opts := []holmes.Option{holmes.WithCPUMax(*cpuMax)}
+	if *interval != "" {
+		opts = append(opts, holmes.WithCollectInterval(*interval))
+	}
+	rules := []struct {
+		name   string
+		rule   string
+		fields int
+	}{
+		{"cpu", *cpuRule, 3},
+		{"mem", *memRule, 3},
+		{"goroutine", *grRule, 4},
+		{"thread", *threadRule, 3},
+	}
+	for _, r := range rules {
+		if r.rule == "" {
+			continue
+		}
+		values, coolDown, err := parseRule(r.rule, r.fields)
+		if err != nil {
+			return fmt.Errorf("invalid %v rule: %w", r.name, err)
+		}
+		switch r.name {
+		case "cpu":
+			opts = append(opts, holmes.WithCPUDump(values[0], values[1], values[2], coolDown))
+		case "mem":
+			opts = append(opts, holmes.WithMemDump(values[0], values[1], values[2], coolDown))
+		case "goroutine":
+			opts = append(opts, holmes.WithGoroutineDump(values[0], values[1], values[2], values[3], coolDown))
+		case "thread":
+			opts = append(opts, holmes.WithThreadDump(values[0], values[1], values[2], coolDown))
+		}
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint: errcheck
+	samples, err := holmes.ReadMetricSamples(f)
+	if err != nil {
+		return err
+	}
+
+	var typeList []string
+	if *types != "" {
+		typeList = strings.Split(*types, ",")
+	}
+	dumps, err := holmes.Simulate(samples, typeList, opts...)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tTYPE\tCURRENT\tAVG\tREASON")
+	counts := make(map[string]int)
+	for _, d := range dumps {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", d.Time.Format(timeLayout), d.Type, d.CurVal, d.Avg, d.Reason)
+		counts[d.Type]++
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d samples, %d dumps", len(samples), len(dumps))
+	for _, name := range []string{"cpu", "mem", "goroutine", "thread"} {
+		if counts[name] > 0 {
+			fmt.Printf(", %s: %d", name, counts[name])
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// parseRule parses the rule like "10,25,80,1m", the fields number of ints followed by the cooldown.
+func parseRule(rule string, fields int) ([]int, time.Duration, error) {
+	parts := strings.Split(rule, ",")
+	if len(parts) != fields+1 {
+		return nil, 0, fmt.Errorf("%q must have %d ints and a cooldown", rule, fields)
+	}
+	values := make([]int, fields)
+	for i := 0; i < fields; i++ {
+		v, err := strconv.Atoi(strings.TrimSpace(parts[i]))
+		if err != nil {
+			return nil, 0, err
+		}
+		values[i] = v
+	}
+	coolDown, err := time.ParseDuration(strings.TrimSpace(parts[fields]))
+	if err != nil {
+		return nil, 0, err
+	}
+	return values, coolDown, nil
+}
@@ -0,0 +1,33 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+// Collector collects the usage of the process in every cycle of the dump loop,
+// it could be replaced by WithCollector, e.g. to script the usage in tests, see holmestest.Collector.
+type Collector interface {
+	// Collect returns the cpu usage in percent of cpuCore, the memory usage in percent of memoryLimit,
+	// the goroutine number and the thread number.
+	Collect(cpuCore float64, memoryLimit uint64) (cpu, mem, goroutine, thread int, err error)
+}
+
+// processCollector collects the usage of the current process by gopsutil and runtime.
+type processCollector struct{}
+
+func (processCollector) Collect(cpuCore float64, memoryLimit uint64) (int, int, int, int, error) {
+	return collect(cpuCore, memoryLimit)
+}
@@ -43,6 +43,34 @@ const (
 	defaultGCHeapTriggerMin  = 10 // 10%
 	defaultGCHeapTriggerAbs  = 40 // 40%
 	defaultGCHeapTriggerDiff = 20 // 20%
+	defaultGCPercent         = 100
+
+	defaultAllocRateTriggerMin      = 100             // 100 MB/s
+	defaultAllocRateTriggerAbs      = 1000            // 1000 MB/s
+	defaultAllocRateTriggerDiff     = 50              // 50%
+	defaultAllocRateCPUSamplingTime = 2 * time.Second // collect 2s cpu profile
+
+	defaultFDTriggerMin  = 10 // 10% of RLIMIT_NOFILE
+	defaultFDTriggerAbs  = 80 // 80% of RLIMIT_NOFILE
+	defaultFDTriggerDiff = 25 // 25%
+
+	defaultMemPressureTriggerMin  = 50 // 50% of memory limit
+	defaultMemPressureTriggerAbs  = 90 // 90% of memory limit
+	defaultMemPressureTriggerDiff = 25 // 25%
+	defaultMemPressureAbs         = 10 // 10% of time stalled on memory
+
+	defaultCPUThrottleTriggerMin  = 5  // 5% of periods throttled
+	defaultCPUThrottleTriggerAbs  = 25 // 25% of periods throttled
+	defaultCPUThrottleTriggerDiff = 50 // 50%
+
+	defaultGoroutineLeakInterval     = time.Minute
+	defaultGoroutineLeakGrowthCycles = 5
+	defaultGoroutineLeakMinWait      = 5 * time.Minute
+
+	defaultDiffTopN                = 10
+	defaultBaselineRefreshInterval = 30 * time.Minute
+
+	defaultEventLogBackups = 3
 
 	defaultCooldown          = time.Minute
 	defaultThreadCoolDown    = time.Hour
@@ -52,6 +80,7 @@ const (
 	defaultDumpProfileType = binaryDump
 	defaultDumpPath        = "/tmp"
 	defaultLoggerName      = "holmes.log"
+	defaultCrashOutputName = "crash.log"
 	defaultLoggerFlags     = os.O_RDWR | os.O_CREATE | os.O_APPEND
 	defaultLoggerPerm      = 0644
 	defaultShardLoggerSize = 5242880 // 5m
@@ -72,39 +101,101 @@ const (
 	thread
 	goroutine
 	gcHeap
+	allocRate
+	fd
+	memPressure
+	cpuThrottle
+	goroutineSummary
+	goroutineLeak
+	heapDiff
+	heapDiffTop
+	goroutineDiff
+	goroutineDiffTop
+	cpuDiff
+	cpuDiffTop
+	manualDump
+	signalDump
+	criticalDump
+	panicDump
+	goroutineStack
 )
 
 // check type to profile name, just align to pprof
 var type2name = map[configureType]string{
-	mem:       "heap",
-	cpu:       "cpu",
-	thread:    "threadcreate",
-	goroutine: "goroutine",
-	gcHeap:    "heap",
+	mem:              "heap",
+	cpu:              "cpu",
+	thread:           "threadcreate",
+	goroutine:        "goroutine",
+	gcHeap:           "heap",
+	allocRate:        "allocs",
+	fd:               "fd",
+	memPressure:      "heap",
+	cpuThrottle:      "cpu",
+	goroutineSummary: "goroutinesummary",
+	goroutineLeak:    "goroutine",
+	heapDiff:         "heap",
+	heapDiffTop:      "heapdifftop",
+	goroutineDiff:    "goroutine",
+	goroutineDiffTop: "goroutinedifftop",
+	cpuDiff:          "cpu",
+	cpuDiffTop:       "cpudifftop",
+	goroutineStack:   "goroutine",
 }
 
 // check type to check name
 var check2name = map[configureType]string{
-	mem:       "mem",
-	cpu:       "cpu",
-	thread:    "thread",
-	goroutine: "goroutine",
-	gcHeap:    "GCHeap",
+	mem:              "mem",
+	cpu:              "cpu",
+	thread:           "thread",
+	goroutine:        "goroutine",
+	gcHeap:           "GCHeap",
+	allocRate:        "alloc",
+	fd:               "fd",
+	memPressure:      "mempressure",
+	cpuThrottle:      "cputhrottle",
+	goroutineSummary: "goroutinesummary",
+	goroutineLeak:    "goroutineleak",
+	heapDiff:         "heapdiff",
+	heapDiffTop:      "heapdifftop",
+	goroutineDiff:    "goroutinediff",
+	goroutineDiffTop: "goroutinedifftop",
+	cpuDiff:          "cpudiff",
+	cpuDiffTop:       "cpudifftop",
+	manualDump:       "manual",
+	signalDump:       "signal",
+	criticalDump:     "critical",
+	panicDump:        "panic",
+	goroutineStack:   "goroutinestack",
 }
 
 const (
 	cgroupMemLimitPath  = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
 	cgroupCpuQuotaPath  = "/sys/fs/cgroup/cpu/cpu.cfs_quota_us"
 	cgroupCpuPeriodPath = "/sys/fs/cgroup/cpu/cpu.cfs_period_us"
+
+	cgroupMemUsagePath      = "/sys/fs/cgroup/memory/memory.usage_in_bytes"
+	cgroupMemStatPath       = "/sys/fs/cgroup/memory/memory.stat"
+	cgroupMemOOMControlPath = "/sys/fs/cgroup/memory/memory.oom_control"
+	cgroupCPUStatPath       = "/sys/fs/cgroup/cpu/cpu.stat"
+
+	// cgroup v2
+	cgroupV2ControllersPath = "/sys/fs/cgroup/cgroup.controllers"
+	cgroupV2MemCurrentPath  = "/sys/fs/cgroup/memory.current"
+	cgroupV2MemMaxPath      = "/sys/fs/cgroup/memory.max"
+	cgroupV2MemStatPath     = "/sys/fs/cgroup/memory.stat"
+	cgroupV2MemEventsPath   = "/sys/fs/cgroup/memory.events"
+	cgroupV2MemPressurePath = "/sys/fs/cgroup/memory.pressure"
+	cgroupV2CPUStatPath     = "/sys/fs/cgroup/cpu.stat"
 )
 
 const minCollectCyclesBeforeDumpStart = 10
 
 const (
-	// TrimResultTopN trimResult return only reserve the top n.
+	// TrimResultTopN is the default top n functions rendered in text mode.
 	TrimResultTopN = 10
 
 	// TrimResultMaxBytes trimResultFront return only reserve the front n bytes.
+	// Deprecated: the text profile is rendered as a top n report instead of trimmed.
 	TrimResultMaxBytes = 512000
 
 	// NotSupportTypeMaxConfig means this profile type is
@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import "time"
+
+// CPUThrottlingStats is the cfs throttling stats of the cgroup, read from cpu.stat.
+type CPUThrottlingStats struct {
+	// the number of enforcement periods that have elapsed.
+	Periods uint64
+	// the number of periods that the cgroup has been throttled.
+	Throttled uint64
+	// the total time that the cgroup has been throttled.
+	ThrottledTime time.Duration
+}
+
+// sub returns the delta stats since prev.
+func (s CPUThrottlingStats) sub(prev CPUThrottlingStats) CPUThrottlingStats {
+	if s.Periods < prev.Periods || s.Throttled < prev.Throttled || s.ThrottledTime < prev.ThrottledTime {
+		// the counters are reset, may be the cgroup is changed.
+		return CPUThrottlingStats{}
+	}
+	return CPUThrottlingStats{
+		Periods:       s.Periods - prev.Periods,
+		Throttled:     s.Throttled - prev.Throttled,
+		ThrottledTime: s.ThrottledTime - prev.ThrottledTime,
+	}
+}
+
+// throttledPercent returns the percent of throttled periods.
+func (s CPUThrottlingStats) throttledPercent() int {
+	if s.Periods == 0 {
+		return 0
+	}
+	return int(s.Throttled * 100 / s.Periods)
+}
+
+// getCGroupCPUThrottlingStats reads the throttling stats from cpu.stat of cgroup v1 or v2.
+func getCGroupCPUThrottlingStats() (CPUThrottlingStats, error) {
+	if isCGroupV2() {
+		stat, err := readFlatKeyed(cgroupV2CPUStatPath)
+		if err != nil {
+			return CPUThrottlingStats{}, err
+		}
+		return CPUThrottlingStats{
+			Periods:       stat["nr_periods"],
+			Throttled:     stat["nr_throttled"],
+			ThrottledTime: time.Duration(stat["throttled_usec"]) * time.Microsecond,
+		}, nil
+	}
+
+	stat, err := readFlatKeyed(cgroupCPUStatPath)
+	if err != nil {
+		return CPUThrottlingStats{}, err
+	}
+	return CPUThrottlingStats{
+		Periods:       stat["nr_periods"],
+		Throttled:     stat["nr_throttled"],
+		ThrottledTime: time.Duration(stat["throttled_time"]),
+	}, nil
+}
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"fmt"
+)
+
+// the profiles dumped in the critical mode.
+var criticalDumpProfiles = []string{"heap", "goroutine"}
+
+// criticalCheckAndDump dumps heap and goroutine profiles at once when the RSS or the GC heap reaches
+// the critical percent of memory limit, ignoring the cooldowns, and only once until both of them drop below it.
+// heapMarked is the GC heap read in the cycle.
+func (h *Holmes) criticalCheckAndDump(rss int, heapMarked uint64, memoryLimit uint64) {
+	percent := h.opts.GetCriticalPercent()
+	if percent <= 0 {
+		return
+	}
+
+	heap := int(100 * float64(heapMarked) / float64(memoryLimit))
+
+	cur, source := rss, check2name[mem]
+	if heap > cur {
+		cur, source = heap, check2name[gcHeap]
+	}
+	if cur < percent {
+		h.criticalTriggered = false
+		return
+	}
+	if h.criticalTriggered {
+		return
+	}
+	h.criticalTriggered = true
+
+	state := h.state(criticalDump)
+	check := check2name[criticalDump]
+	now := h.now()
+	seq := state.next(now, now)
+	d := &DumpContext{
+		h:       h,
+		ctx:     h.runContext(),
+		Check:   check,
+		EventID: fmt.Sprintf("%s-%d", check, seq),
+		Reason:  ReasonCritical,
+		Scene: Scene{
+			typeOption: typeOption{TriggerAbs: percent},
+			Check:      check,
+			CurVal:     cur,
+			Critical:   true,
+		},
+		fsync: true,
+	}
+	h.Warnf("[Holmes] %v %v%% reaches the critical percent %v%% of memory limit, dump %v now",
+		source, cur, percent, d.EventID)
+
+	if _, err := h.dumpEvent(d, criticalDumpProfiles); err != nil {
+		h.Errorf("[Holmes] critical dump failed: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCriticalCheckAndDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-critical")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "events.log")
+
+	h, err := New(WithDumpPath(dir), WithEventLog(path, 0, 1), WithCriticalDump(90))
+	assert.Nil(t, err)
+	assert.NotNil(t, h.Set(WithCriticalDump(101)))
+
+	// the GC heap is far below the limit.
+	limit := uint64(math.MaxInt64)
+	h.criticalCheckAndDump(50, 0, limit)
+	assert.Equal(t, 0, h.state(criticalDump).count())
+
+	// dump once, ignoring the cooldown of the mem check.
+	h.state(mem).setCoolDown(h.now().Add(time.Minute))
+	h.criticalCheckAndDump(95, 0, limit)
+	h.criticalCheckAndDump(96, 0, limit)
+	assert.Equal(t, 1, h.state(criticalDump).count())
+
+	events := readCheckEvents(t, path)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "critical", events[0].Check)
+	assert.Equal(t, "critical-0", events[0].EventID)
+	assert.Equal(t, ReasonCritical.String(), events[0].Reason)
+	assert.Equal(t, 95, events[0].Current)
+	assert.Equal(t, 2, len(events[0].Files))
+
+	// dump again after it drops below the critical percent.
+	h.criticalCheckAndDump(80, 0, limit)
+	h.criticalCheckAndDump(95, 0, limit)
+	assert.Equal(t, 2, h.state(criticalDump).count())
+
+	// the GC heap reaches the limit.
+	h.criticalCheckAndDump(10, 0, limit)
+	h.criticalCheckAndDump(10, limit, limit)
+	assert.Equal(t, 3, h.state(criticalDump).count())
+}
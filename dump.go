@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// Dump writes the profiles now, e.g. from a panic handler or a slow request detector, regardless of
+// the cooldowns and CPUMaxPercent. the profiles are some of heap, cpu, threadcreate, goroutine and allocs,
+// they are written to the dump path in the event "manual-<n>", reported with ReasonManual, and recorded in the event log.
+// the cpu profile is sampled for CPUSamplingTime, and canceled only when ctx is done, even holmes is stopped,
+// the profiles are still written after holmes is stopped, while they are not reported.
+// it returns the event ID and the written files, and an error when any of the profiles failed.
+func (h *Holmes) Dump(ctx context.Context, profiles ...string) (string, []string, error) {
+	return h.dumpProfiles(ctx, manualDump, ReasonManual, false, profiles)
+}
+
+// DumpIfAllowed writes the profiles like Dump, but it refuses to dump when the current cpu usage exceeds CPUMaxPercent,
+// or any check which dumps these profiles is in cooldown, e.g. the mem check for the heap profile.
+func (h *Holmes) DumpIfAllowed(ctx context.Context, profiles ...string) (string, []string, error) {
+	return h.dumpProfiles(ctx, manualDump, ReasonManual, true, profiles)
+}
+
+// dumpProfiles writes the profiles in the event of the check type, e.g. "manual-1".
+func (h *Holmes) dumpProfiles(ctx context.Context, typ configureType, reason ReasonType,
+	respectLimits bool, profiles []string) (string, []string, error) {
+	if len(profiles) == 0 {
+		return "", nil, fmt.Errorf("no profile to dump")
+	}
+	for _, profile := range profiles {
+		if _, ok := profile2type[profile]; !ok {
+			return "", nil, fmt.Errorf("unsupported profile %v", profile)
+		}
+	}
+
+	if respectLimits {
+		if err := h.manualDumpAllowed(profiles); err != nil {
+			return "", nil, err
+		}
+	}
+
+	state := h.state(typ)
+	check := check2name[typ]
+	now := h.now()
+	// take the sequence of the event ID atomically, since Dump could be called concurrently.
+	seq := state.next(now, state.coolDownUntil())
+	d := &DumpContext{
+		h:       h,
+		ctx:     ctx,
+		Check:   check,
+		EventID: fmt.Sprintf("%s-%d", check, seq),
+		Reason:  reason,
+		Scene:   Scene{Check: check},
+	}
+
+	files, err := h.dumpEvent(d, profiles)
+	return d.EventID, files, err
+}
+
+// dumpEvent writes the profiles in the event of d, and records it in the event log,
+// it returns the written files, and an error when any of the profiles failed.
+func (h *Holmes) dumpEvent(d *DumpContext, profiles []string) ([]string, error) {
+	c := d.Scene.typeOption
+	var errs []string
+	for _, profile := range profiles {
+		if _, err := d.WriteProfile(profile); err != nil {
+			h.Errorf("[Holmes] failed to dump %v profile for %v: %v", profile, d.Check, err)
+			errs = append(errs, fmt.Sprintf("%v: %v", profile, err))
+		}
+	}
+
+	if len(d.files) == 0 {
+		err := fmt.Errorf("dump %v failed: %v", d.EventID, strings.Join(errs, "; "))
+		h.logDumpFailed(d.Check, c, NotSupportTypeMaxConfig, ring{}, d.Scene.CurVal, d.Reason, d.EventID, err)
+		return nil, err
+	}
+
+	h.logDump(d.Check, c, NotSupportTypeMaxConfig, ring{}, d.Scene.CurVal, d.Reason, 0, d.EventID, d.files...)
+	if len(errs) > 0 {
+		return d.files, fmt.Errorf("dump %v partially failed: %v", d.EventID, strings.Join(errs, "; "))
+	}
+	return d.files, nil
+}
+
+// manualDumpAllowed returns an error when the latest cpu usage exceeds CPUMaxPercent,
+// or the check of any profile is in cooldown.
+func (h *Holmes) manualDumpAllowed(profiles []string) error {
+	stats := h.state(cpu).history()
+	if usage, ok := stats.latest(); ok {
+		if err := h.EnableDump(usage); err != nil {
+			return err
+		}
+	}
+
+	now := h.now()
+	for _, profile := range profiles {
+		typ := profile2type[profile]
+		if until := h.state(typ).coolDownUntil(); until.After(now) {
+			return fmt.Errorf("%v dump is in cooldown until %v", check2name[typ], until)
+		}
+	}
+	return nil
+}
+
+// the profiles dumped on the dump signal.
+var signalDumpProfiles = []string{"goroutine", "heap", "threadcreate", "cpu"}
+
+// signalDumpLoop dumps the profiles in an event on each signal, until ctx is done.
+func (h *Holmes) signalDumpLoop(ctx context.Context, sigCh chan os.Signal) {
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case sig := <-sigCh:
+			h.Infof("[Holmes] received signal %v, start to dump", sig)
+			eventID, files, err := h.dumpProfiles(ctx, signalDump, ReasonSignal, false, signalDumpProfiles)
+			if err != nil {
+				h.Errorf("[Holmes] dump on signal %v: %v", sig, err)
+			}
+			h.Infof("[Holmes] dumped on signal %v, event ID: %v, files: %v", sig, eventID, files)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
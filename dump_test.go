@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManualDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-dump")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "events.log")
+
+	h, err := New(WithDumpPath(dir), WithEventLog(path, 0, 1))
+	assert.Nil(t, err)
+
+	_, _, err = h.Dump(context.Background())
+	assert.NotNil(t, err)
+	_, _, err = h.Dump(context.Background(), "block")
+	assert.NotNil(t, err)
+
+	eventID, files, err := h.Dump(context.Background(), "goroutine", "heap")
+	assert.Nil(t, err)
+	assert.Equal(t, "manual-0", eventID)
+	assert.Equal(t, 2, len(files))
+	assert.True(t, strings.HasPrefix(filepath.Base(files[0]), "goroutine.manual-0."))
+	assert.True(t, strings.HasPrefix(filepath.Base(files[1]), "mem.manual-0."))
+
+	events := readCheckEvents(t, path)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "manual", events[0].Check)
+	assert.Equal(t, DecisionDump, events[0].Decision)
+	assert.Equal(t, ReasonManual.String(), events[0].Reason)
+	assert.Equal(t, files, events[0].Files)
+
+	// the cpu sampling is canceled by ctx.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	eventID, files, err = h.Dump(ctx, "cpu")
+	assert.Nil(t, err)
+	assert.Equal(t, "manual-1", eventID)
+	assert.Equal(t, 1, len(files))
+	assert.True(t, time.Since(start) < h.opts.CPUSamplingTime)
+}
+
+func TestConcurrentDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-dump")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	h, err := New(WithDumpPath(dir))
+	assert.Nil(t, err)
+
+	const n = 10
+	ids := make(chan string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			eventID, _, err := h.Dump(context.Background(), "goroutine")
+			assert.Nil(t, err)
+			ids <- eventID
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool, n)
+	for id := range ids {
+		assert.False(t, seen[id], id)
+		seen[id] = true
+	}
+	assert.Equal(t, n, len(seen))
+	assert.Equal(t, n, h.state(manualDump).count())
+}
+
+func TestDumpAfterStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-dump")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	h, err := New(WithCollectInterval("1h"), WithDumpPath(dir), WithCPUSamplingTime("200ms"))
+	assert.Nil(t, err)
+	h.Start()
+	assert.Nil(t, h.Shutdown(context.Background()))
+
+	// the cpu profile is sampled for CPUSamplingTime, not canceled by the stopped holmes.
+	start := time.Now()
+	_, files, err := h.Dump(context.Background(), "cpu")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestDumpIfAllowed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-dump")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	h, err := New(WithDumpPath(dir), WithCPUMax(80))
+	assert.Nil(t, err)
+
+	h.state(mem).setCoolDown(time.Now().Add(time.Minute))
+	_, _, err = h.DumpIfAllowed(context.Background(), "goroutine", "heap")
+	assert.NotNil(t, err)
+	_, files, err := h.Dump(context.Background(), "heap")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	h.state(cpu).push(90)
+	_, _, err = h.DumpIfAllowed(context.Background(), "goroutine")
+	assert.NotNil(t, err)
+
+	h.state(cpu).push(10)
+	_, files, err = h.DumpIfAllowed(context.Background(), "goroutine")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+}
@@ -0,0 +1,244 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CheckDecision is the decision made by a check in a cycle.
+type CheckDecision string
+
+const (
+	// DecisionDump means the rule is matched and the profiles are dumped.
+	DecisionDump CheckDecision = "dump"
+	// DecisionNoDump means the rule is not matched.
+	DecisionNoDump CheckDecision = "nodump"
+	// DecisionCoolDown means the check is skipped since the previous dump is in cooldown.
+	DecisionCoolDown CheckDecision = "cooldown"
+	// DecisionFailed means the rule is matched but failed to dump the profile.
+	DecisionFailed CheckDecision = "failed"
+)
+
+// CheckEvent is a line of the event log, records the inputs and the decision of a check.
+type CheckEvent struct {
+	Time     time.Time     `json:"time"`
+	Check    string        `json:"check"`
+	Decision CheckDecision `json:"decision"`
+	// Reason is the ReasonType of the decision, empty in cooldown.
+	Reason string `json:"reason,omitempty"`
+
+	Current  int   `json:"current"`
+	Avg      int   `json:"avg"`
+	Previous []int `json:"previous,omitempty"`
+
+	ConfigMin  int `json:"config_min"`
+	ConfigDiff int `json:"config_diff"`
+	ConfigAbs  int `json:"config_abs"`
+	ConfigMax  int `json:"config_max"`
+
+	// CoolDownUntil is the time the check starts again, set in cooldown or after dump.
+	CoolDownUntil *time.Time `json:"cooldown_until,omitempty"`
+
+	EventID string   `json:"event_id,omitempty"`
+	Files   []string `json:"files,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// newCheckEvent returns the event of the check with its inputs.
+func (h *Holmes) newCheckEvent(check string, decision CheckDecision, c typeOption, max int, stats ring, cur int) CheckEvent {
+	return CheckEvent{
+		Time:       h.now(),
+		Check:      check,
+		Decision:   decision,
+		Current:    cur,
+		Avg:        stats.avg(),
+		Previous:   stats.sequentialData(),
+		ConfigMin:  c.TriggerMin,
+		ConfigDiff: c.TriggerDiff,
+		ConfigAbs:  c.TriggerAbs,
+		ConfigMax:  max,
+	}
+}
+
+// logNoDump logs why the check does not dump, in debug level when the event log is enabled,
+// since it's recorded in the event log already.
+func (h *Holmes) logNoDump(check string, c typeOption, max int, stats ring, cur int, reason ReasonType) {
+	level := LevelInfo
+	if h.opts.GetEventLogOpts().Path != "" {
+		level = LevelDebug
+	}
+	h.logw(level, checkFields(check, &reason, ""), UniformLogFormat, "NODUMP", check,
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs, max, stats.sequentialData(), cur)
+
+	e := h.newCheckEvent(check, DecisionNoDump, c, max, stats, cur)
+	e.Reason = reason.String()
+	h.logCheckEvent(e)
+}
+
+// logCoolDown logs the check is skipped until the cooldown time.
+func (h *Holmes) logCoolDown(check string, cur int, until time.Time) {
+	h.logw(LevelDebug, checkFields(check, nil, ""), "[Holmes] %v dump is in cooldown", check)
+
+	h.logCheckEvent(CheckEvent{
+		Time:          h.now(),
+		Check:         check,
+		Decision:      DecisionCoolDown,
+		Current:       cur,
+		CoolDownUntil: &until,
+	})
+}
+
+// logDump logs the dumped files of the check, the check starts again after coolDown, 0 means no cooldown.
+func (h *Holmes) logDump(check string, c typeOption, max int, stats ring, cur int,
+	reason ReasonType, coolDown time.Duration, eventID string, files ...string) {
+	e := h.newCheckEvent(check, DecisionDump, c, max, stats, cur)
+	e.Reason = reason.String()
+	if coolDown > 0 {
+		until := e.Time.Add(coolDown)
+		e.CoolDownUntil = &until
+	}
+	e.EventID = eventID
+	for _, f := range files {
+		if f != "" {
+			e.Files = append(e.Files, f)
+		}
+	}
+	h.logCheckEvent(e)
+}
+
+// logDumpFailed logs the rule is matched but failed to dump.
+func (h *Holmes) logDumpFailed(check string, c typeOption, max int, stats ring, cur int,
+	reason ReasonType, eventID string, err error) {
+	e := h.newCheckEvent(check, DecisionFailed, c, max, stats, cur)
+	e.Reason = reason.String()
+	e.EventID = eventID
+	e.Error = err.Error()
+	h.logCheckEvent(e)
+}
+
+// eventLogger appends the events to the event log file, the file is kept open and its size is
+// tracked in memory, it's reopened only after rotating or the path is changed.
+type eventLogger struct {
+	// the GC heap check runs in another goroutine.
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+// write appends the line to the file at opts.Path, rotates it first when it would exceed MaxSize.
+func (l *eventLogger) write(opts eventLogOptions, line []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f != nil && l.path != opts.Path {
+		l.closeFile()
+	}
+	if l.f == nil {
+		if err := l.open(opts.Path); err != nil {
+			return err
+		}
+	}
+
+	n := int64(len(line))
+	if l.size > 0 && l.size+n > opts.MaxSize {
+		l.closeFile()
+		if err := rotateEventLog(opts.Path, opts.MaxBackups); err != nil {
+			return fmt.Errorf("rotate: %w", err)
+		}
+		if err := l.open(opts.Path); err != nil {
+			return err
+		}
+	}
+
+	written, err := l.f.Write(line)
+	l.size += int64(written)
+	return err
+}
+
+func (l *eventLogger) open(path string) error {
+	f, err := os.OpenFile(path, defaultLoggerFlags, defaultLoggerPerm)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close() // nolint: errcheck
+		return fmt.Errorf("stat: %w", err)
+	}
+	l.path, l.f, l.size = path, f, fi.Size()
+	return nil
+}
+
+// close closes the file, it's opened again by the next write.
+func (l *eventLogger) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closeFile()
+}
+
+func (l *eventLogger) closeFile() {
+	if l.f == nil {
+		return
+	}
+	l.f.Close() // nolint: errcheck
+	l.f, l.size = nil, 0
+}
+
+// logCheckEvent appends the event to the event log in json lines, it's rotated by size.
+func (h *Holmes) logCheckEvent(e CheckEvent) {
+	opts := h.opts.GetEventLogOpts()
+	if opts.Path == "" {
+		return
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	if err := h.eventLog.write(opts, line); err != nil {
+		h.Errorf("[Holmes] failed to write event log %v: %v", opts.Path, err)
+	}
+}
+
+// rotateEventLog renames path to path.1, path.1 to path.2 and so on, only maxBackups files are kept.
+func rotateEventLog(path string, maxBackups int) error {
+	if maxBackups == 0 {
+		return os.Remove(path)
+	}
+
+	backup := func(i int) string {
+		return fmt.Sprintf("%s.%d", path, i)
+	}
+	if err := os.Remove(backup(maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, backup(1))
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readCheckEvents(t *testing.T, path string) []CheckEvent {
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer f.Close() // nolint: errcheck
+
+	var events []CheckEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e CheckEvent
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &e))
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestEventLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-eventlog")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "events.log")
+
+	h, err := New(
+		WithDumpPath(dir),
+		WithEventLog(path, 0, 1),
+		WithGoroutineDump(10, 25, 100, 0, time.Minute),
+	)
+	assert.Nil(t, err)
+	h.EnableGoroutineDump()
+
+	var gr Checker
+	for _, c := range h.getCheckers() {
+		if c.Name() == check2name[goroutine] {
+			gr = c
+		}
+	}
+	for _, n := range []int{5, 200, 200} {
+		h.state(goroutine).push(n)
+		h.checkAndDump(gr, n)
+	}
+
+	events := readCheckEvents(t, path)
+	assert.Equal(t, 3, len(events))
+
+	assert.Equal(t, DecisionNoDump, events[0].Decision)
+	assert.Equal(t, "goroutine", events[0].Check)
+	assert.Equal(t, ReasonCurlLessMin.String(), events[0].Reason)
+	assert.Equal(t, 5, events[0].Current)
+	assert.Equal(t, 100, events[0].ConfigAbs)
+
+	assert.Equal(t, DecisionDump, events[1].Decision)
+	assert.Equal(t, ReasonCurGreaterAbs.String(), events[1].Reason)
+	assert.Equal(t, 1, len(events[1].Files))
+	assert.FileExists(t, events[1].Files[0])
+	assert.NotNil(t, events[1].CoolDownUntil)
+
+	assert.Equal(t, DecisionCoolDown, events[2].Decision)
+	assert.Equal(t, 200, events[2].Current)
+	assert.WithinDuration(t, *events[1].CoolDownUntil, *events[2].CoolDownUntil, time.Second)
+}
+
+func TestRotateEventLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-eventlog")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "events.log")
+
+	var l eventLogger
+	defer l.close()
+	opts := eventLogOptions{Path: path, MaxSize: 10, MaxBackups: 2}
+	write := func(content string) {
+		assert.Nil(t, l.write(opts, []byte(content)))
+	}
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(name)
+		assert.Nil(t, err)
+		return string(data)
+	}
+
+	write("aaaaa")
+	write("bbbbb")
+	assert.Equal(t, "aaaaabbbbb", read(path))
+
+	write("ccccc")
+	write("ddddd")
+	write("eeeee")
+	write("fffff")
+	write("ggggg")
+	assert.Equal(t, "ggggg", read(path))
+	assert.Equal(t, "eeeeefffff", read(path+".1"))
+	assert.Equal(t, "cccccddddd", read(path+".2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// no backup
+	opts.MaxSize, opts.MaxBackups = 5, 0
+	write("hhhhh")
+	assert.Equal(t, "hhhhh", read(path))
+	assert.Equal(t, "eeeeefffff", read(path+".1"))
+}
+
+func TestEventLoggerWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-eventlog")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "events.log")
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(name)
+		assert.Nil(t, err)
+		return string(data)
+	}
+
+	// the size of the existing file is counted.
+	assert.Nil(t, ioutil.WriteFile(path, []byte("aaaaa"), defaultLoggerPerm))
+
+	var l eventLogger
+	defer l.close()
+	opts := eventLogOptions{Path: path, MaxSize: 10, MaxBackups: 1}
+	for _, line := range []string{"bbbbb", "ccccc"} {
+		assert.Nil(t, l.write(opts, []byte(line)))
+	}
+	f := l.f
+	assert.Equal(t, "ccccc", read(path))
+	assert.Equal(t, "aaaaabbbbb", read(path+".1"))
+
+	// the file is kept open until rotating.
+	assert.Nil(t, l.write(opts, []byte("dd")))
+	assert.Equal(t, f, l.f)
+	assert.Nil(t, l.write(opts, []byte("eeeee")))
+	assert.NotEqual(t, f, l.f)
+	assert.Equal(t, "eeeee", read(path))
+	assert.Equal(t, "cccccdd", read(path+".1"))
+
+	// reopened when the path is changed.
+	opts.Path = path + ".new"
+	assert.Nil(t, l.write(opts, []byte("fffff")))
+	assert.Equal(t, "fffff", read(opts.Path))
+}
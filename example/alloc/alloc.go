@@ -25,6 +25,7 @@ import (
 	mlog "mosn.io/pkg/log"
 
 	"mosn.io/holmes"
+	"mosn.io/holmes/mosnlog"
 )
 
 // run `curl http://localhost:10003/alloc` after 15s(warn up)
@@ -37,7 +38,7 @@ func main() {
 	h, _ := holmes.New(
 		holmes.WithCollectInterval("2s"),
 		holmes.WithDumpPath("./tmp"),
-		holmes.WithLogger(holmes.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
+		mosnlog.WithLogger(mosnlog.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
 		holmes.WithTextDump(),
 		holmes.WithMemDump(3, 25, 80, time.Minute),
 	)
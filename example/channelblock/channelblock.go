@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"mosn.io/holmes"
+	"mosn.io/holmes/mosnlog"
 )
 
 // run `curl http://localhost:10003/chanblock` after 15s(warn up)
@@ -35,7 +36,7 @@ func main() {
 	h, _ := holmes.New(
 		holmes.WithCollectInterval("5s"),
 		holmes.WithDumpPath("./tmp"),
-		holmes.WithLogger(holmes.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
+		mosnlog.WithLogger(mosnlog.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
 		holmes.WithTextDump(),
 		holmes.WithGoroutineDump(10, 25, 2000, 10000, time.Minute),
 	)
@@ -24,6 +24,7 @@ import (
 	mlog "mosn.io/pkg/log"
 
 	"mosn.io/holmes"
+	"mosn.io/holmes/mosnlog"
 )
 
 // run `curl http://localhost:10003/cpuex` after 15s(warn up)
@@ -36,7 +37,7 @@ func main() {
 	h, _ := holmes.New(
 		holmes.WithCollectInterval("2s"),
 		holmes.WithDumpPath("./tmp"),
-		holmes.WithLogger(holmes.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
+		mosnlog.WithLogger(mosnlog.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
 		holmes.WithCPUDump(20, 25, 80, time.Minute),
 	)
 	h.EnableCPUDump().Start()
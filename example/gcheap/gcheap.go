@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"mosn.io/holmes"
+	"mosn.io/holmes/mosnlog"
 )
 
 // run `curl http://localhost:10024/rand` after 15s(warn up)
@@ -42,7 +43,7 @@ func main() {
 	// reporter := http_reporter.NewReporter("TOKEN", "URL")
 	h, _ := holmes.New(
 		holmes.WithDumpPath("./tmp"),
-		holmes.WithLogger(holmes.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
+		mosnlog.WithLogger(mosnlog.NewFileLog("./tmp/holmes.log", mlog.DEBUG)),
 		holmes.WithBinaryDump(),
 		holmes.WithMemoryLimit(100*1024*1024), // 100MB
 		holmes.WithGCHeapDump(10, 20, 40, time.Minute),
@@ -4,18 +4,21 @@ go 1.17
 
 require (
 	mosn.io/holmes v0.0.0-20220125114618-8cb365eb42ac
-	mosn.io/pkg v0.0.0-20220308091858-ea728aacbe63
+	mosn.io/pkg v1.6.0
 )
 
 require (
 	github.com/StackExchange/wmi v0.0.0-20190523213315-cbe66965904d // indirect
 	github.com/go-ole/go-ole v1.2.4 // indirect
+	github.com/golang/protobuf v1.5.0 // indirect
+	github.com/google/pprof v0.0.0-20211214055906-6f57359322fd // indirect
 	github.com/hashicorp/go-syslog v1.0.0 // indirect
 	github.com/rcrowley/go-metrics v0.0.0-20200313005456-10cdbea86bc0 // indirect
 	github.com/shirou/gopsutil v3.20.11+incompatible // indirect
-	golang.org/x/sys v0.0.0-20210809222454-d867a43fc93e // indirect
+	golang.org/x/sys v0.0.0-20220227234510-4e6760a101f9 // indirect
+	google.golang.org/protobuf v1.26.0-rc.1 // indirect
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0 // indirect
-	mosn.io/api v0.0.0-20210204052134-5b9a826795fd // indirect
+	mosn.io/api v1.5.0 // indirect
 )
 
 replace mosn.io/holmes => ../../
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"mosn.io/holmes"
+	"mosn.io/holmes/mosnlog"
 	"mosn.io/holmes/reporters/pyroscope_reporter"
 	"rideshare/bike"
 	"rideshare/car"
@@ -72,7 +73,7 @@ func main() {
 		"region": region,
 	}
 
-	pReporter, err := pyroscope_reporter.NewPyroscopeReporter("holmes-client", tags, cfg, holmes.NewStdLogger())
+	pReporter, err := pyroscope_reporter.NewPyroscopeReporter("holmes-client", tags, cfg, mosnlog.NewStdLogger())
 	if err != nil {
 		fmt.Printf("NewPyroscopeReporter error %v\n", err)
 		return
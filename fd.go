@@ -0,0 +1,257 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	procSelfFDPath = "/proc/self/fd"
+	socketPrefix   = "socket:["
+)
+
+var procNetTCPPaths = []string{"/proc/self/net/tcp", "/proc/self/net/tcp6"}
+
+// tcp states defined in include/net/tcp_states.h
+var tcpStates = map[uint64]string{
+	0x01: "ESTABLISHED",
+	0x02: "SYN_SENT",
+	0x03: "SYN_RECV",
+	0x04: "FIN_WAIT1",
+	0x05: "FIN_WAIT2",
+	0x06: "TIME_WAIT",
+	0x07: "CLOSE",
+	0x08: "CLOSE_WAIT",
+	0x09: "LAST_ACK",
+	0x0A: "LISTEN",
+	0x0B: "CLOSING",
+}
+
+// fdInfo is an open file descriptor and its target.
+type fdInfo struct {
+	fd     int
+	target string
+}
+
+// fdSample is the open fds collected in a cycle.
+type fdSample struct {
+	// the percent of open fds to the soft limit of RLIMIT_NOFILE.
+	usage int
+	num   int
+	limit uint64
+
+	// the open fds, the tcp sockets and the counts by tcp state, only collected when the tcp states are enabled.
+	fds       []fdInfo
+	sockets   map[string]tcpSocket
+	tcpStates map[string]int
+}
+
+// tcpSocket is a tcp socket entry of /proc/net/tcp.
+type tcpSocket struct {
+	local  string
+	remote string
+	state  string
+}
+
+// getFDNum returns the number of open file descriptors of current process.
+func getFDNum() (int, error) {
+	d, err := os.Open(procSelfFDPath)
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close() // nolint: errcheck
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
+	// the fd opened by ourself is included
+	return len(names) - 1, nil
+}
+
+// listFDs returns the open file descriptors with their targets, sorted by fd.
+func listFDs() ([]fdInfo, error) {
+	d, err := os.Open(procSelfFDPath)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close() // nolint: errcheck
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	// skip the fd opened by ourself, like getFDNum.
+	self := int(d.Fd())
+	fds := make([]fdInfo, 0, len(names))
+	for _, name := range names {
+		fd, err := strconv.Atoi(name)
+		if err != nil || fd == self {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join(procSelfFDPath, name))
+		if err != nil {
+			// the fd may be closed already.
+			continue
+		}
+		fds = append(fds, fdInfo{fd: fd, target: target})
+	}
+	sort.Slice(fds, func(i, j int) bool { return fds[i].fd < fds[j].fd })
+	return fds, nil
+}
+
+// getTCPSockets returns the tcp sockets of the current network namespace, keyed by inode.
+func getTCPSockets() (map[string]tcpSocket, error) {
+	sockets := make(map[string]tcpSocket)
+	for _, path := range procNetTCPPaths {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		err = parseProcNetTCP(f, sockets)
+		f.Close() // nolint: errcheck
+		if err != nil {
+			return nil, fmt.Errorf("parse %v failed: %w", path, err)
+		}
+	}
+	return sockets, nil
+}
+
+// parseProcNetTCP parses the content of /proc/net/tcp or /proc/net/tcp6.
+func parseProcNetTCP(r io.Reader, sockets map[string]tcpSocket) error {
+	scanner := bufio.NewScanner(r)
+	// skip the header line
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		if len(fields) < 10 {
+			continue
+		}
+		local, err := parseProcNetAddr(fields[1])
+		if err != nil {
+			return err
+		}
+		remote, err := parseProcNetAddr(fields[2])
+		if err != nil {
+			return err
+		}
+		st, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return err
+		}
+		state, ok := tcpStates[st]
+		if !ok {
+			state = "UNKNOWN"
+		}
+		sockets[fields[9]] = tcpSocket{local: local, remote: remote, state: state}
+	}
+	return scanner.Err()
+}
+
+// parseProcNetAddr parses address like "0100007F:1F90" to "127.0.0.1:8080".
+// the ip is stored as 32 bits words in host byte order(little endian here).
+func parseProcNetAddr(s string) (string, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	b, err := hex.DecodeString(parts[0])
+	if err != nil || (len(b) != net.IPv4len && len(b) != net.IPv6len) {
+		return "", fmt.Errorf("invalid ip %q", parts[0])
+	}
+	for i := 0; i < len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q", parts[1])
+	}
+	return net.JoinHostPort(net.IP(b).String(), strconv.FormatUint(port, 10)), nil
+}
+
+// socketInode returns the inode of the socket fd target like "socket:[12345]".
+func socketInode(target string) (string, bool) {
+	if !strings.HasPrefix(target, socketPrefix) || !strings.HasSuffix(target, "]") {
+		return "", false
+	}
+	return target[len(socketPrefix) : len(target)-1], true
+}
+
+// countTCPStates counts the tcp sockets owned by the given fds by state.
+func countTCPStates(fds []fdInfo, sockets map[string]tcpSocket) map[string]int {
+	states := make(map[string]int)
+	for _, fd := range fds {
+		inode, ok := socketInode(fd.target)
+		if !ok {
+			continue
+		}
+		if sock, ok := sockets[inode]; ok {
+			states[sock.state]++
+		}
+	}
+	return states
+}
+
+// formatFDs formats the open fds with their targets,
+// tcp sockets are attached with their addresses and states when sockets is not nil.
+func formatFDs(fds []fdInfo, limit uint64, sockets map[string]tcpSocket) bytes.Buffer {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "fd count: %d, limit: %d\n", len(fds), limit)
+
+	if sockets != nil {
+		states := countTCPStates(fds, sockets)
+		names := make([]string, 0, len(states))
+		for name := range states {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		buf.WriteString("tcp states:")
+		for _, name := range names {
+			fmt.Fprintf(&buf, " %s=%d", name, states[name])
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	for _, fd := range fds {
+		fmt.Fprintf(&buf, "%d -> %s", fd.fd, fd.target)
+		if inode, ok := socketInode(fd.target); ok && sockets != nil {
+			if sock, ok := sockets[inode]; ok {
+				fmt.Fprintf(&buf, " tcp %s -> %s %s", sock.local, sock.remote, sock.state)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf
+}
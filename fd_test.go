@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcNetAddr(t *testing.T) {
+	addr, err := parseProcNetAddr("0100007F:1F90")
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:8080", addr)
+
+	addr, err = parseProcNetAddr("00000000000000000000000001000000:0050")
+	assert.Nil(t, err)
+	assert.Equal(t, "[::1]:80", addr)
+
+	_, err = parseProcNetAddr("0100007F")
+	assert.NotNil(t, err)
+}
+
+func TestListFDs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc/self/fd is linux only")
+	}
+	fds, err := listFDs()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, fds)
+	// the fd of /proc/self/fd opened by listFDs is not listed, so the count matches getFDNum.
+	self := fmt.Sprintf("/proc/%d/fd", os.Getpid())
+	for _, fd := range fds {
+		assert.NotEqual(t, self, fd.target)
+	}
+}
+
+func TestFormatFDs(t *testing.T) {
+	content := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 662 1 00000000ccefca07 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:B48C 08 00000000:00000000 00:00000000 00000000     0        0 1666 2 000000002825e6fd 20 4 16 18 -1
+`
+	sockets := make(map[string]tcpSocket)
+	assert.Nil(t, parseProcNetTCP(strings.NewReader(content), sockets))
+	assert.Equal(t, tcpSocket{local: "127.0.0.1:8080", remote: "127.0.0.1:46220", state: "CLOSE_WAIT"}, sockets["1666"])
+
+	fds := []fdInfo{
+		{fd: 0, target: "/dev/null"},
+		{fd: 3, target: "socket:[662]"},
+		{fd: 4, target: "socket:[1666]"},
+	}
+	buf := formatFDs(fds, 1024, sockets)
+	assert.Equal(t, `fd count: 3, limit: 1024
+tcp states: CLOSE_WAIT=1 LISTEN=1
+
+0 -> /dev/null
+3 -> socket:[662] tcp 127.0.0.1:8080 -> 0.0.0.0:0 LISTEN
+4 -> socket:[1666] tcp 127.0.0.1:8080 -> 127.0.0.1:46220 CLOSE_WAIT
+`, buf.String())
+}
+
+func TestFDNumMatch(t *testing.T) {
+	h, err := New(WithFDDump(10, 25, 80, time.Minute), WithFDNumAbs(100))
+	assert.Nil(t, err)
+	assert.NotNil(t, h.Set(WithFDNumAbs(-1)))
+
+	h.cycle.fd = fdSample{num: 100}
+	match, _ := h.fdNumMatch()
+	assert.False(t, match)
+
+	h.cycle.fd = fdSample{num: 101}
+	match, reason := h.fdNumMatch()
+	assert.True(t, match)
+	assert.Equal(t, ReasonFDNumGreaterAbs, reason)
+}
+
+func TestCollectTCPStates(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fd check relies on procfs")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close() // nolint: errcheck
+
+	h, err := New(WithFDDump(10, 25, 80, time.Minute), WithFDTCPStates(true))
+	assert.Nil(t, err)
+	h.EnableFDDump()
+
+	sample, ok := h.collectFDUsage()
+	assert.True(t, ok)
+	assert.Equal(t, len(sample.fds), sample.num)
+	assert.True(t, sample.tcpStates["LISTEN"] >= 1)
+}
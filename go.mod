@@ -4,6 +4,7 @@ go 1.14
 
 require (
 	github.com/gin-gonic/gin v1.7.7
+	github.com/google/pprof v0.0.0-20211214055906-6f57359322fd
 	github.com/shirou/gopsutil v3.20.11+incompatible
 	github.com/stretchr/testify v1.7.0
 	mosn.io/pkg v1.6.0
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"time"
+
+	"mosn.io/holmes/internal/inspect"
+)
+
+// goroutineLeakDetector finds the goroutine groups whose count grows monotonically
+// over successive goroutine snapshots, which are likely to be leaked.
+type goroutineLeakDetector struct {
+	// the counts of the goroutine groups in the recent snapshots, keyed by the group key.
+	history map[string][]int
+}
+
+func newGoroutineLeakDetector() *goroutineLeakDetector {
+	return &goroutineLeakDetector{history: make(map[string][]int)}
+}
+
+// observe records the goroutine groups of a snapshot, and returns the leaking groups,
+// whose count grows in each of the recent growthCycles snapshots, and the longest wait is at least minWait.
+func (d *goroutineLeakDetector) observe(groups []GoroutineGroup, growthCycles int, minWait time.Duration) []GoroutineGroup {
+	var leaks []GoroutineGroup
+	history := make(map[string][]int, len(groups))
+	for _, g := range groups {
+		key := inspect.GroupKey(g.State, g.Stack, g.CreatedBy)
+		counts := append(d.history[key], g.Count)
+		if len(counts) > growthCycles+1 {
+			counts = counts[len(counts)-growthCycles-1:]
+		}
+		// the groups not in this snapshot are dropped, since they are not growing.
+		history[key] = counts
+
+		if len(counts) == growthCycles+1 && isGrowing(counts) && g.MaxWait >= minWait {
+			leaks = append(leaks, g)
+		}
+	}
+	d.history = history
+	return leaks
+}
+
+// isGrowing returns whether the counts are strictly increasing.
+func isGrowing(counts []int) bool {
+	for i := 1; i < len(counts); i++ {
+		if counts[i] <= counts[i-1] {
+			return false
+		}
+	}
+	return true
+}
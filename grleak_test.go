@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoroutineLeakDetector(t *testing.T) {
+	d := newGoroutineLeakDetector()
+	leaking := func(count int, wait time.Duration) GoroutineGroup {
+		return GoroutineGroup{State: "chan receive", Count: count, MaxWait: wait, Stack: []string{"main.leak"}, CreatedBy: "main.main"}
+	}
+	stable := GoroutineGroup{State: "IO wait", Count: 10, MaxWait: time.Hour, Stack: []string{"net.accept"}}
+
+	// grows 3 cycles, but wait is too short
+	for i := 1; i <= 3; i++ {
+		assert.Empty(t, d.observe([]GoroutineGroup{leaking(i*10, 0), stable}, 2, time.Minute))
+	}
+	// grows with long wait
+	leaks := d.observe([]GoroutineGroup{leaking(40, 30*time.Minute), stable}, 2, time.Minute)
+	assert.Equal(t, []GoroutineGroup{leaking(40, 30*time.Minute)}, leaks)
+
+	// stops growing
+	assert.Empty(t, d.observe([]GoroutineGroup{leaking(40, 31*time.Minute), stable}, 2, time.Minute))
+
+	// disappears and comes back, the history is restarted
+	assert.Empty(t, d.observe([]GoroutineGroup{stable}, 2, time.Minute))
+	assert.Empty(t, d.observe([]GoroutineGroup{leaking(50, time.Hour)}, 2, time.Minute))
+	assert.Empty(t, d.observe([]GoroutineGroup{leaking(60, time.Hour)}, 2, time.Minute))
+	assert.NotEmpty(t, d.observe([]GoroutineGroup{leaking(70, time.Hour)}, 2, time.Minute))
+}
@@ -0,0 +1,58 @@
+//go:build !go1.21
+// +build !go1.21
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// getHeapMarked returns the heap marked by the last GC cycle.
+// runtime doesn't expose it before go1.21, so estimate it by NextGC and GOGC.
+func getHeapMarked(memStats *runtime.MemStats) uint64 {
+	return estimateHeapMarked(memStats, getGCPercent())
+}
+
+var (
+	gcPercentOnce sync.Once
+	gcPercent     int
+)
+
+// getGCPercent returns the GOGC percent from the environment, -1 means GC is off.
+// runtime doesn't expose it before go1.21 except debug.SetGCPercent, which stops the world
+// and races with the user's setting, so it's cached and the changes by debug.SetGCPercent are not seen.
+func getGCPercent() int {
+	gcPercentOnce.Do(func() {
+		gcPercent = defaultGCPercent
+		switch env := os.Getenv("GOGC"); env {
+		case "":
+		case "off":
+			gcPercent = -1
+		default:
+			if percent, err := strconv.Atoi(env); err == nil {
+				gcPercent = percent
+			}
+		}
+	})
+	return gcPercent
+}
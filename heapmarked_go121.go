@@ -0,0 +1,56 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"runtime"
+	"runtime/metrics"
+)
+
+const (
+	heapLiveMetric  = "/gc/heap/live:bytes"
+	gcPercentMetric = "/gc/gogc:percent"
+)
+
+// getHeapMarked returns the heap marked by the last GC cycle,
+// which is exposed by runtime/metrics since go1.21.
+func getHeapMarked(memStats *runtime.MemStats) uint64 {
+	sample := []metrics.Sample{{Name: heapLiveMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return estimateHeapMarked(memStats, getGCPercent())
+	}
+	return sample[0].Value.Uint64()
+}
+
+// getGCPercent returns the GOGC percent, -1 means GC is off.
+// it's read from runtime/metrics, since debug.SetGCPercent stops the world and races with the user's setting.
+func getGCPercent() int {
+	sample := []metrics.Sample{{Name: gcPercentMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return defaultGCPercent
+	}
+	if percent := int64(sample[0].Value.Uint64()); percent >= 0 {
+		return int(percent)
+	}
+	return -1
+}
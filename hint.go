@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/pprof/profile"
+
+	"mosn.io/holmes/internal/inspect"
+)
+
+// hintTopN is the number of functions or goroutine stacks in the root-cause hint of an alert.
+const hintTopN = 3
+
+// uniformAlertFormat is UniformLogFormat followed by the root-cause hint derived from the dumped profile.
+const uniformAlertFormat = UniformLogFormat + ", hint: %v"
+
+// profileHint returns the top n functions ranked by the flat value of the sample type in the binary profile,
+// e.g. "main.leak 3.00MB(75.00%), main.cache 1.00MB(25.00%)".
+func profileHint(data []byte, sampleType string, n int) string {
+	p, err := profile.ParseData(data)
+	if err != nil || len(p.SampleType) == 0 {
+		return "unknown"
+	}
+	idx := inspect.SampleIndex(p, sampleType)
+	st := p.SampleType[idx]
+
+	var total int64
+	for _, s := range p.Sample {
+		total += s.Value[idx]
+	}
+
+	entries := inspect.AggregateByFunc(p, idx)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Flat > entries[j].Flat
+	})
+
+	var hints []string
+	for _, e := range entries {
+		if len(hints) >= n || e.Flat <= 0 {
+			break
+		}
+		hints = append(hints, fmt.Sprintf("%s %s(%.2f%%)",
+			e.Func, inspect.FormatValue(e.Flat, st.Unit, false), float64(e.Flat)*100/float64(total)))
+	}
+	if len(hints) == 0 {
+		return "unknown"
+	}
+	return strings.Join(hints, ", ")
+}
+
+// goroutineHint returns the top n goroutine stacks by count in the binary goroutine profile,
+// each stack is named by its first function out of the runtime, e.g. "120 main.worker, 3 main.main".
+func goroutineHint(data []byte, n int) string {
+	p, err := profile.ParseData(data)
+	if err != nil || len(p.SampleType) == 0 {
+		return "unknown"
+	}
+
+	counts := make(map[string]int64)
+	var names []string
+	for _, s := range p.Sample {
+		name := stackName(s)
+		if _, ok := counts[name]; !ok {
+			names = append(names, name)
+		}
+		counts[name] += s.Value[0]
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return counts[names[i]] > counts[names[j]]
+	})
+
+	var hints []string
+	for _, name := range names {
+		if len(hints) >= n {
+			break
+		}
+		hints = append(hints, fmt.Sprintf("%d %s", counts[name], name))
+	}
+	if len(hints) == 0 {
+		return "unknown"
+	}
+	return strings.Join(hints, ", ")
+}
+
+// stackName returns the first function out of the runtime from the leaf, or the leaf function.
+func stackName(s *profile.Sample) string {
+	leaf := ""
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function == nil {
+				continue
+			}
+			name := line.Function.Name
+			if leaf == "" {
+				leaf = name
+			}
+			if !strings.HasPrefix(name, "runtime.") {
+				return name
+			}
+		}
+	}
+	if leaf == "" {
+		return "unknown"
+	}
+	return leaf
+}
@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileHint(t *testing.T) {
+	data := newTestHeapProfile(t, map[string]int64{"main.leak": 3 << 20, "main.stable": 1 << 20})
+	assert.Equal(t, "main.leak 3.00MB(75.00%), main.stable 1.00MB(25.00%)", profileHint(data, "inuse_space", 3))
+	assert.Equal(t, "main.leak 3.00MB(75.00%)", profileHint(data, "inuse_space", 1))
+	assert.Equal(t, "unknown", profileHint(nil, "cpu", 3))
+}
+
+func TestGoroutineHint(t *testing.T) {
+	ch := make(chan struct{})
+	defer close(ch)
+	for i := 0; i < 5; i++ {
+		go hintTestBlocked(ch)
+	}
+	// wait for the goroutines to block.
+	time.Sleep(100 * time.Millisecond)
+
+	var buf bytes.Buffer
+	assert.Nil(t, pprof.Lookup("goroutine").WriteTo(&buf, 0))
+	assert.Regexp(t, `^5 mosn\.io/holmes\.hintTestBlocked`, goroutineHint(buf.Bytes(), 1))
+	assert.Equal(t, "unknown", goroutineHint([]byte("not a profile"), 3))
+}
+
+func hintTestBlocked(ch chan struct{}) {
+	<-ch
+}
+
+func TestCPUDumpAlertBeforeSampling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-hint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	rec := &recordLogger{}
+	h, err := New(WithStructuredLogger(rec), WithDumpPath(dir), WithCPUSamplingTime("100ms"))
+	assert.Nil(t, err)
+	newContext := func() *DumpContext {
+		return &DumpContext{h: h, ctx: context.Background(), Check: "cpu", EventID: "cpu-0",
+			Reason: ReasonCurGreaterAbs, stats: newRing(2)}
+	}
+
+	// the alert is logged even the sampling failed.
+	assert.Nil(t, pprof.StartCPUProfile(ioutil.Discard))
+	err = h.cpuDump(newContext())
+	pprof.StopCPUProfile()
+	assert.NotNil(t, err)
+	assert.Equal(t, LevelError, rec.records[0].level)
+	assert.Equal(t, []interface{}{LogKeyAlert, "holmes.cpu"}, rec.records[0].keyvals[:2])
+
+	rec.records = nil
+	d := newContext()
+	assert.Nil(t, h.cpuDump(d))
+	assert.Equal(t, []interface{}{LogKeyAlert, "holmes.cpu"}, rec.records[0].keyvals[:2])
+	assert.NotEmpty(t, d.Scene.Hint)
+	last := rec.records[len(rec.records)-1]
+	assert.Equal(t, "[Holmes] cpu dump hint: "+d.Scene.Hint, last.msg)
+}
@@ -19,65 +19,88 @@ package holmes
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"os/signal"
 	"runtime"
 	"runtime/pprof"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"mosn.io/holmes/internal/inspect"
 )
 
 // Holmes is a self-aware profile dumper.
 type Holmes struct {
 	opts *options
 
-	// stats
-	collectCount             int
-	gcCycleCount             int
-	threadTriggerCount       int
-	cpuTriggerCount          int
-	memTriggerCount          int
-	grTriggerCount           int
-	gcHeapTriggerCount       int
-	shrinkThreadTriggerCount int
-
-	// cooldown
-	threadCoolDownTime    time.Time
-	cpuCoolDownTime       time.Time
-	memCoolDownTime       time.Time
-	gcHeapCoolDownTime    time.Time
-	grCoolDownTime        time.Time
-	shrinkThrCoolDownTime time.Time
+	// the number of cycles collected since start, accessed atomically.
+	collectCount int64
+	gcCycleCount int
+
+	// the built-in and registered checkers, and the stats, cooldown and trigger count of each check.
+	checkersMu  sync.RWMutex
+	checkers    []Checker
+	checkStates map[string]*checkState
+	// the values collected in the current cycle of the dump loop, read by the built-in checkers.
+	cycle cycleSample
+	// the cooldown and trigger count of shrinking thread.
+	shrinkState *checkState
 
 	// GC heap triggered, need to dump next time.
 	gcHeapTriggered bool
-
-	// stats ring
-	memStats    ring
-	cpuStats    ring
-	grNumStats  ring
-	threadStats ring
-	gcHeapStats ring
+	// critical dump triggered, no more critical dump until the memory drops below the critical percent.
+	criticalTriggered bool
+	// the binary heap profile of the first GC heap dump, to diff with the next one.
+	gcHeapPrevProfile []byte
+	// the binary profiles captured in a quiet period to diff with.
+	baselines profileBaselines
+	// the event log and the metrics record files kept open between the writes.
+	eventLog   eventLogger
+	metricsLog eventLogger
+
+	// the memory events of previous collect, to find out the increased counters.
+	lastMemEvents *memEvents
+	// the cpu throttling stats of previous collect, to calc the delta.
+	lastCPUThrottling *CPUThrottlingStats
+
+	// goroutine leak detector and the time of previous goroutine snapshot.
+	grLeakDetector     *goroutineLeakDetector
+	lastGrLeakSnapshot time.Time
+
+	// the total allocated bytes and time of previous collect, to calc the alloc rate.
+	lastTotalAlloc uint64
+	lastAllocTime  time.Time
 
 	// switch
 	stopped int64
+	// drop the queued reports when shutdown timed out.
+	dropReports int32
+
+	// wait for the dump loop, the reporter, the GC heap check loop and the thread shrinking.
+	wg sync.WaitGroup
 
 	// lock Protect the following
 	sync.Mutex
-	// channel for GC sweep finalizer event
-	gcEventsCh chan struct{}
 	// profiler reporter channels
 	rptEventsCh chan rptEvent
+	// the state of the current start.
+	run *runState
 }
 
 // New creates a holmes dumper.
 func New(opts ...Option) (*Holmes, error) {
 	holmes := &Holmes{
 
-		opts:    newOptions(),
-		stopped: 1, // Initialization should be off
+		opts:        newOptions(),
+		shrinkState: newCheckState(minCollectCyclesBeforeDumpStart),
+		stopped:     1, // Initialization should be off
 	}
+	holmes.checkers = holmes.newBuiltinCheckers()
+	holmes.checkStates = newCheckStates(holmes.checkers)
 
 	for _, opt := range opts {
 		if err := opt.apply(holmes.opts); err != nil {
@@ -90,73 +113,177 @@ func New(opts ...Option) (*Holmes, error) {
 
 // EnableThreadDump enables the goroutine dump.
 func (h *Holmes) EnableThreadDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.threadOpts.Enable = true
+	h.opts.L.Unlock()
 	return h
 }
 
 // DisableThreadDump disables the goroutine dump.
 func (h *Holmes) DisableThreadDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.threadOpts.Enable = false
+	h.opts.L.Unlock()
 	return h
 }
 
 // EnableGoroutineDump enables the goroutine dump.
 func (h *Holmes) EnableGoroutineDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.grOpts.Enable = true
+	h.opts.L.Unlock()
 	return h
 }
 
 // DisableGoroutineDump disables the goroutine dump.
 func (h *Holmes) DisableGoroutineDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.grOpts.Enable = false
+	h.opts.L.Unlock()
 	return h
 }
 
 // EnableCPUDump enables the CPU dump.
 func (h *Holmes) EnableCPUDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.cpuOpts.Enable = true
+	h.opts.L.Unlock()
 	return h
 }
 
 // DisableCPUDump disables the CPU dump.
 func (h *Holmes) DisableCPUDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.cpuOpts.Enable = false
+	h.opts.L.Unlock()
 	return h
 }
 
 // EnableMemDump enables the mem dump.
 func (h *Holmes) EnableMemDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.memOpts.Enable = true
+	h.opts.L.Unlock()
 	return h
 }
 
 // DisableMemDump disables the mem dump.
 func (h *Holmes) DisableMemDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.memOpts.Enable = false
+	h.opts.L.Unlock()
 	return h
 }
 
 // EnableGCHeapDump enables the GC heap dump.
 func (h *Holmes) EnableGCHeapDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.gCHeapOpts.Enable = true
+	h.opts.L.Unlock()
 	return h
 }
 
 // DisableGCHeapDump disables the gc heap dump.
 func (h *Holmes) DisableGCHeapDump() *Holmes {
+	h.opts.L.Lock()
 	h.opts.gCHeapOpts.Enable = false
+	h.opts.L.Unlock()
+	return h
+}
+
+// EnableAllocRateDump enables the allocation rate dump.
+func (h *Holmes) EnableAllocRateDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.allocOpts.Enable = true
+	h.opts.L.Unlock()
+	return h
+}
+
+// DisableAllocRateDump disables the allocation rate dump.
+func (h *Holmes) DisableAllocRateDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.allocOpts.Enable = false
+	h.opts.L.Unlock()
+	return h
+}
+
+// EnableFDDump enables the fd dump.
+func (h *Holmes) EnableFDDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.fdOpts.Enable = true
+	h.opts.L.Unlock()
+	return h
+}
+
+// DisableFDDump disables the fd dump.
+func (h *Holmes) DisableFDDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.fdOpts.Enable = false
+	h.opts.L.Unlock()
+	return h
+}
+
+// EnableMemPressureDump enables the memory pressure dump.
+func (h *Holmes) EnableMemPressureDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.memPressureOpts.Enable = true
+	h.opts.L.Unlock()
+	return h
+}
+
+// DisableMemPressureDump disables the memory pressure dump.
+func (h *Holmes) DisableMemPressureDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.memPressureOpts.Enable = false
+	h.opts.L.Unlock()
+	return h
+}
+
+// EnableCPUThrottleDump enables the cpu throttle dump.
+func (h *Holmes) EnableCPUThrottleDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.cpuThrottleOpts.Enable = true
+	h.opts.L.Unlock()
+	return h
+}
+
+// DisableCPUThrottleDump disables the cpu throttle dump.
+func (h *Holmes) DisableCPUThrottleDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.cpuThrottleOpts.Enable = false
+	h.opts.L.Unlock()
+	return h
+}
+
+// EnableGoroutineLeakDump enables the goroutine leak detector.
+func (h *Holmes) EnableGoroutineLeakDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.grLeakOpts.Enable = true
+	h.opts.L.Unlock()
+	return h
+}
+
+// DisableGoroutineLeakDump disables the goroutine leak detector.
+func (h *Holmes) DisableGoroutineLeakDump() *Holmes {
+	h.opts.L.Lock()
+	h.opts.grLeakOpts.Enable = false
+	h.opts.L.Unlock()
 	return h
 }
 
 // EnableShrinkThread enables shrink thread
 func (h *Holmes) EnableShrinkThread() *Holmes {
+	h.opts.L.Lock()
 	h.opts.ShrinkThrOptions.Enable = true
+	h.opts.L.Unlock()
 	return h
 }
 
 // DisableShrinkThread disables shrink thread
 func (h *Holmes) DisableShrinkThread() *Holmes {
+	h.opts.L.Lock()
 	h.opts.ShrinkThrOptions.Enable = false
+	h.opts.L.Unlock()
 	return h
 }
 
@@ -166,23 +293,17 @@ func finalizerCallback(gc *gcHeapFinalizer) {
 			gc.h.Errorf("Panic in finalizer callback: %v", r)
 		}
 	}()
-	// disable or stop gc clean up normally
-	if atomic.LoadInt64(&gc.h.stopped) == 1 {
+	// stop gc clean up normally, the run is stopped, it may be started again with a new finalizer.
+	if gc.run.ctx.Err() != nil {
 		return
 	}
 
 	// register the finalizer again
 	runtime.SetFinalizer(gc, finalizerCallback)
 
-	// read channel should be atomic.
-	ch := gc.h.gcEventsCh
-	if ch == nil {
-		return
-	}
-	// Notice: here may be a litte race, will panic when ch is closed now.
-	// we just leave it since it is very small and there is a recover.
+	// the channel is never closed, the check loop exits when the run is stopped.
 	select {
-	case ch <- struct{}{}:
+	case gc.ch <- struct{}{}:
 	default:
 		gc.h.Errorf("can not send event to finalizer channel immediately, may be analyzer blocked?")
 	}
@@ -190,23 +311,50 @@ func finalizerCallback(gc *gcHeapFinalizer) {
 
 // it won't fit into tiny span since this struct contains point.
 type gcHeapFinalizer struct {
-	h *Holmes
+	h   *Holmes
+	run *runState
+	ch  chan struct{}
 }
 
-func (h *Holmes) startGCCycleLoop(ch chan struct{}) {
-	h.gcHeapStats = newRing(minCollectCyclesBeforeDumpStart)
-
+func (h *Holmes) startGCCycleLoop(run *runState, prev *runState) {
 	gc := &gcHeapFinalizer{
-		h,
+		h:   h,
+		run: run,
+		ch:  make(chan struct{}, 1),
 	}
 
 	runtime.SetFinalizer(gc, finalizerCallback)
 
-	go gc.h.gcHeapCheckLoop(ch)
+	run.loops.Add(1)
+	h.goWait(func() {
+		defer run.loops.Done()
+		if prev != nil {
+			prev.loops.Wait()
+		}
+		h.resetGCHeapState()
+		h.gcHeapCheckLoop(run.ctx, gc.ch)
+	})
+}
+
+// runState is the state of a start, a new one is created when holmes is started again after stopped.
+type runState struct {
+	// ctx is canceled when holmes is stopped, to cancel the in-flight sampling.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// the dump loop and the GC heap check loop, the loops of the next start wait for them
+	// before resetting the state, since they write the same state.
+	loops sync.WaitGroup
 }
 
 // Start starts the dump loop of holmes.
+// it could be started again after stopped, the collected stats, cooldowns and warming up are reset,
+// while the trigger counts are kept to make the event IDs unique.
 func (h *Holmes) Start() {
+	h.StartContext(context.Background())
+}
+
+// StartContext starts the dump loop of holmes, which is stopped when ctx is done.
+func (h *Holmes) StartContext(ctx context.Context) {
 	h.Lock()
 	defer h.Unlock()
 
@@ -216,55 +364,168 @@ func (h *Holmes) Start() {
 		return
 	}
 
-	gcEventsCh := make(chan struct{}, 1)
+	prev := h.run
+	run := &runState{}
+	run.ctx, run.cancel = context.WithCancel(ctx)
+	h.run = run
+
 	rptCh := make(chan rptEvent, 32)
-	h.gcEventsCh = gcEventsCh
 	h.rptEventsCh = rptCh
+	atomic.StoreInt32(&h.dropReports, 0)
 
 	h.initEnvironment()
-	go h.startDumpLoop()
-	go h.startReporter(rptCh)
+	run.loops.Add(1)
+	h.goWait(func() {
+		defer run.loops.Done()
+		if prev != nil {
+			prev.loops.Wait()
+		}
+		h.startDumpLoop(run.ctx)
+	})
+	h.goWait(func() {
+		h.startReporter(rptCh)
+	})
+	h.goWait(func() {
+		<-run.ctx.Done()
+		h.stop(run)
+	})
+	if sigs := h.opts.GetDumpSignals(); len(sigs) > 0 {
+		// notify before start, the process may be killed by the signal otherwise.
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, sigs...)
+		h.goWait(func() {
+			h.signalDumpLoop(run.ctx, sigCh)
+		})
+	}
 
-	h.startGCCycleLoop(gcEventsCh)
+	h.startGCCycleLoop(run, prev)
 }
 
-// Stop the dump loop.
+// Stop the dump loop, it doesn't wait for the in-flight dumps and reports, use Shutdown to wait for them.
 func (h *Holmes) Stop() {
+	if !h.stop(nil) {
+		h.Infof("[Holmes] holmes has stopped, please don't stop it again")
+	}
+}
+
+// Shutdown stops holmes, cancels the in-flight sampling, and waits for all the goroutines of holmes
+// until the queued reports are sent. it returns an error when ctx is done before that, the reports not sent
+// yet are dropped then, they could be sent again from the dump files by the holmes command.
+func (h *Holmes) Shutdown(ctx context.Context) error {
+	h.stop(nil)
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		h.eventLog.close()
+		h.metricsLog.close()
+		return nil
+	case <-ctx.Done():
+		atomic.StoreInt32(&h.dropReports, 1)
+		return fmt.Errorf("holmes shutdown: %w", ctx.Err())
+	}
+}
+
+// stop flips the stopped flag, cancels the in-flight sampling and closes the report channel,
+// only when it's the current run if run is not nil, returns false if it's stopped already.
+func (h *Holmes) stop(run *runState) bool {
 	h.Lock()
 	defer h.Unlock()
 
+	if run != nil && run != h.run {
+		// stopped and started again.
+		return false
+	}
 	if !atomic.CompareAndSwapInt64(&h.stopped, 0, 1) {
-		//nolint
-		fmt.Println("Holmes has stop, please don't stop it again.")
-		return
+		return false
 	}
 
-	if gcEventsCh := h.gcEventsCh; gcEventsCh != nil {
-		h.gcEventsCh = nil
-		close(gcEventsCh)
+	if h.run != nil {
+		h.run.cancel()
 	}
 	if rptEventsCh := h.rptEventsCh; rptEventsCh != nil {
 		h.rptEventsCh = nil
 		close(rptEventsCh)
 	}
+	return true
+}
+
+// goWait runs f in a goroutine which is waited by Shutdown.
+func (h *Holmes) goWait(f func()) {
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		f()
+	}()
+}
+
+// runContext returns the context canceled when holmes is stopped.
+func (h *Holmes) runContext() context.Context {
+	h.Lock()
+	defer h.Unlock()
+	if h.run == nil {
+		return context.Background()
+	}
+	return h.run.ctx
+}
+
+// sleep waits for d, returns false when holmes is stopped before that.
+func (h *Holmes) sleep(d time.Duration) bool {
+	return h.sleepContext(h.runContext(), d)
+}
+
+// sleepContext waits for d, returns false when ctx is done before that,
+// the checks pass the run context to be canceled when holmes is stopped.
+func (h *Holmes) sleepContext(ctx context.Context, d time.Duration) bool {
+	t := h.opts.GetClock().NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// resetGCHeapState resets the state of the GC heap check on start.
+func (h *Holmes) resetGCHeapState() {
+	h.state(gcHeap).reset(time.Time{})
+	h.gcCycleCount = 0
+	h.gcHeapTriggered = false
+	h.gcHeapPrevProfile = nil
 }
 
-func (h *Holmes) startDumpLoop() {
-	// init previous cool down time
-	now := time.Now()
-	h.cpuCoolDownTime = now
-	h.memCoolDownTime = now
-	h.grCoolDownTime = now
+func (h *Holmes) startDumpLoop(ctx context.Context) {
+	// init stats ring and previous cool down time
+	h.resetCheckStates(h.now())
 
-	// init stats ring
-	h.cpuStats = newRing(minCollectCyclesBeforeDumpStart)
-	h.memStats = newRing(minCollectCyclesBeforeDumpStart)
-	h.grNumStats = newRing(minCollectCyclesBeforeDumpStart)
-	h.threadStats = newRing(minCollectCyclesBeforeDumpStart)
+	// warming up again
+	atomic.StoreInt64(&h.collectCount, 0)
+	h.lastMemEvents = nil
+	h.lastCPUThrottling = nil
+	h.criticalTriggered = false
+
+	// init goroutine leak detector
+	h.grLeakDetector = newGoroutineLeakDetector()
+	h.lastGrLeakSnapshot = time.Time{}
+
+	// init the total allocated bytes
+	memStats := new(runtime.MemStats)
+	runtime.ReadMemStats(memStats)
+	h.collectAllocRate(memStats)
 
 	// dump loop
-	ticker := time.NewTicker(h.opts.CollectInterval)
-	defer ticker.Stop()
+	clock := h.opts.GetClock()
+	ticker := clock.NewTicker(h.opts.GetCollectInterval())
+	defer func() {
+		ticker.Stop()
+	}()
 
 	for {
 		select {
@@ -273,16 +534,20 @@ func (h *Holmes) startDumpLoop() {
 			// can use Reset API directly here. pkg.go.dev/time#Ticker.Reset
 			// we can't use the `for-range` here, because the range loop
 			// caches the variable to be lopped and then it can't be overwritten
-			itv := h.opts.CollectInterval
+			itv := h.opts.GetCollectInterval()
 			h.Infof("[Holmes] collect interval is resetting to [%v]\n", itv) //nolint:forbidigo
-			ticker = time.NewTicker(itv)
+			ticker.Stop()
+			ticker = clock.NewTicker(itv)
 
 		default:
 			// bug fix: https://github.com/mosn/holmes/issues/63
 			// make sure that the message inside intervalResetting channel
 			// would be consumed before ticker.C.
-			<-ticker.C
-			if atomic.LoadInt64(&h.stopped) == 1 {
+			select {
+			case <-ticker.C():
+			case <-ctx.Done():
+			}
+			if atomic.LoadInt64(&h.stopped) == 1 || ctx.Err() != nil {
 				h.Infof("[Holmes] dump loop stopped") //nolint:forbidigo
 				return
 			}
@@ -299,134 +564,128 @@ func (h *Holmes) startDumpLoop() {
 				return
 			}
 
-			cpu, mem, gNum, tNum, err := collect(cpuCore, memoryLimit)
+			cpuUsage, rss, gNum, tNum, err := h.opts.GetCollector().Collect(cpuCore, memoryLimit)
 			if err != nil {
 				h.Errorf("failed to collect resource usage: %v", err.Error())
 
 				continue
 			}
 
-			h.cpuStats.push(cpu)
-			h.memStats.push(mem)
-			h.grNumStats.push(gNum)
-			h.threadStats.push(tNum)
+			h.cycle = h.collectCycle(MetricSample{Time: h.now(), CPU: cpuUsage, Mem: rss, Goroutine: gNum, Thread: tNum})
+			h.recordMetrics(h.cycle.MetricSample)
+			// the process may be killed soon, don't wait for warming up or the cpu.
+			h.criticalCheckAndDump(rss, h.cycle.heapMarked, memoryLimit)
+
+			checkers := h.getCheckers()
+			values := make([]int, len(checkers))
+			collected := make([]bool, len(checkers))
+			for i, c := range checkers {
+				if values[i], collected[i] = c.Collect(); collected[i] {
+					h.stateOf(c.Name()).push(values[i])
+				}
+			}
 
-			h.collectCount++
-			if h.collectCount < minCollectCyclesBeforeDumpStart {
+			collectCount := atomic.AddInt64(&h.collectCount, 1)
+			if collectCount < minCollectCyclesBeforeDumpStart {
 				// at least collect some cycles
 				// before start to judge and dump
-				h.Debugf("[Holmes] warming up cycle : %d", h.collectCount)
+				h.Debugf("[Holmes] warming up cycle : %d", collectCount)
 
 				continue
 			}
 
-			if err := h.EnableDump(cpu); err != nil {
+			if collectCount == minCollectCyclesBeforeDumpStart {
+				h.captureBaselines()
+			}
+
+			if err := h.EnableDump(cpuUsage); err != nil {
 				h.Infof("[Holmes] unable to dump: %v", err)
 
 				continue
 			}
 
-			h.memCheckAndDump(mem)
-			h.cpuCheckAndDump(cpu)
-			h.threadCheckAndDump(tNum)
+			for i, c := range checkers {
+				if collected[i] {
+					h.checkAndDump(c, values[i])
+				}
+			}
 			h.threadCheckAndShrink(tNum)
-			h.goroutineCheckAndDump(gNum)
+			h.goroutineLeakCheckAndDump()
+			h.refreshBaselines()
 		}
 	}
 }
 
-// goroutine start.
-func (h *Holmes) goroutineCheckAndDump(gNum int) {
-	// get a copy instead of locking it
-	grOpts := h.opts.GetGrOpts()
-	if !grOpts.Enable {
-		return
-	}
-
-	if h.grCoolDownTime.After(time.Now()) {
-		h.Debugf("[Holmes] goroutine dump is in cooldown")
-		return
-	}
-	// grOpts is a struct, no escape.
-	if triggered := h.goroutineProfile(gNum, grOpts); triggered {
-		h.grCoolDownTime = time.Now().Add(grOpts.CoolDown)
-		h.grTriggerCount++
-	}
-}
+// cycleSample is the values collected in a cycle of the dump loop.
+type cycleSample struct {
+	MetricSample
+	allocRate int
+	// the heap marked by the previous GC cycle.
+	heapMarked uint64
 
-func (h *Holmes) goroutineProfile(gNum int, c grOptions) bool {
-	match, reason := matchRule(h.grNumStats, gNum, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, c.GoroutineTriggerNumMax)
-	if !match {
-		h.Infof(UniformLogFormat, "NODUMP", check2name[goroutine],
-			c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
-			c.GoroutineTriggerNumMax, h.grNumStats.sequentialData(), gNum)
-		return false
-	}
+	fd          fdSample
+	fdCollected bool
 
-	h.Alertf("holmes.goroutine", UniformLogFormat, "pprof ", check2name[goroutine],
-		c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
-		c.GoroutineTriggerNumMax,
-		h.grNumStats.sequentialData(), gNum)
+	memPressure          memPressureSample
+	memPressureCollected bool
 
-	var buf bytes.Buffer
-	_ = pprof.Lookup("goroutine").WriteTo(&buf, int(h.opts.DumpProfileType)) // nolint: errcheck
+	throttling          CPUThrottlingStats
+	throttlingCollected bool
+}
 
-	scene := Scene{
-		typeOption: *c.typeOption,
-		CurVal:     gNum,
-		Avg:        h.grNumStats.avg(),
-	}
+// collectCycle collects the values of the built-in checks besides the sample of the collector.
+func (h *Holmes) collectCycle(sample MetricSample) cycleSample {
+	c := cycleSample{MetricSample: sample}
 
-	h.ReportProfile(type2name[goroutine], h.writeProfileDataToFile(buf, goroutine, ""),
-		reason, "", time.Now(), buf.Bytes(), scene)
-	return true
+	// read once for the checks in the cycle, since it stops the world.
+	memStats := new(runtime.MemStats)
+	runtime.ReadMemStats(memStats)
+	c.allocRate = h.collectAllocRate(memStats)
+	c.heapMarked = getHeapMarked(memStats)
+	c.fd, c.fdCollected = h.collectFDUsage()
+	c.memPressure, c.memPressureCollected = h.collectMemPressure()
+	c.throttling, c.throttlingCollected = h.collectCPUThrottling()
+	return c
 }
 
-// memory start.
-func (h *Holmes) memCheckAndDump(mem int) {
-	// get a copy instead of locking it
-	memOpts := h.opts.GetMemOpts()
-	if !memOpts.Enable {
-		return
-	}
+// goroutine start.
+func (h *Holmes) goroutineDump(d *DumpContext) error {
+	grOpts := h.opts.GetGrOpts()
+	c := d.Scene.typeOption
 
-	if h.memCoolDownTime.After(time.Now()) {
-		h.Debugf("[Holmes] mem dump is in cooldown")
-		return
-	}
-	// memOpts is a struct, no escape.
-	if triggered := h.memProfile(mem, memOpts); triggered {
-		h.memCoolDownTime = time.Now().Add(memOpts.CoolDown)
-		h.memTriggerCount++
-	}
-}
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, h.opts.profileDebug(goroutine)) // nolint: errcheck
 
-func (h *Holmes) memProfile(rss int, c typeOption) bool {
-	match, reason := matchRule(h.memStats, rss, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, NotSupportTypeMaxConfig)
-	if !match {
-		// let user know why this should not dump
-		h.Infof(UniformLogFormat, "NODUMP", check2name[mem],
-			c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
-			h.memStats.sequentialData(), rss)
+	h.alertCheck("holmes.goroutine", d.Check, d.Reason, d.EventID, uniformAlertFormat, "pprof ", d.Check,
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
+		grOpts.GoroutineTriggerNumMax,
+		d.stats.sequentialData(), d.Scene.CurVal,
+		goroutineHint(h.binaryProfile("goroutine", buf.Bytes()), hintTopN))
 
-		return false
+	if grOpts.SummaryTopN > 0 {
+		d.Scene.GoroutineGroups = h.writeGoroutineSummary(grOpts.SummaryTopN, d.EventID)
 	}
 
-	h.Alertf("holmes.memory", UniformLogFormat, "pprof", check2name[mem],
-		c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
-		NotSupportTypeMaxConfig, h.memStats, rss)
+	fileName, data := h.writeProfileDataToFile(buf, goroutine, d.EventID)
+	d.report(type2name[goroutine], fileName, data)
+	return nil
+}
+
+// memory start.
+func (h *Holmes) memDump(d *DumpContext) error {
+	c := d.Scene.typeOption
 
 	var buf bytes.Buffer
-	_ = pprof.Lookup("heap").WriteTo(&buf, int(h.opts.DumpProfileType)) // nolint: errcheck
+	_ = pprof.Lookup("heap").WriteTo(&buf, h.opts.profileDebug(mem)) // nolint: errcheck
 
-	scene := Scene{
-		typeOption: c,
-		CurVal:     rss,
-		Avg:        h.memStats.avg(),
-	}
+	h.alertCheck("holmes.memory", d.Check, d.Reason, d.EventID, uniformAlertFormat, "pprof", d.Check,
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
+		NotSupportTypeMaxConfig, d.stats, d.Scene.CurVal,
+		profileHint(h.binaryProfile("heap", buf.Bytes()), "inuse_space", hintTopN))
 
-	h.ReportProfile(type2name[mem], h.writeProfileDataToFile(buf, mem, ""), reason, "", time.Now(), buf.Bytes(), scene)
-	return true
+	fileName, data := h.writeProfileDataToFile(buf, mem, d.EventID)
+	d.report(type2name[mem], fileName, data)
+	return nil
 }
 
 func (h *Holmes) threadCheckAndShrink(threadNum int) {
@@ -436,7 +695,7 @@ func (h *Holmes) threadCheckAndShrink(threadNum int) {
 		return
 	}
 
-	if h.shrinkThrCoolDownTime.After(time.Now()) {
+	if h.shrinkState.coolDownUntil().After(h.now()) {
 		return
 	}
 
@@ -450,40 +709,19 @@ func (h *Holmes) threadCheckAndShrink(threadNum int) {
 		if delay > time.Hour*24 {
 			delay = time.Hour * 24
 		}
-		h.shrinkThrCoolDownTime = time.Now().Add(delay)
+		h.shrinkState.setCoolDown(h.now().Add(delay))
 
 		h.Alertf("holmes.thread", "current thread number(%v) larger than threshold(%v), will start to shrink thread after %v", threadNum, opts.Threshold, opts.Delay)
 
 		// do not shrink thread immediately
-		time.AfterFunc(opts.Delay, func() {
-			h.startShrinkThread()
+		h.goWait(func() {
+			if h.sleep(opts.Delay) {
+				h.startShrinkThread()
+			}
 		})
 	}
 }
 
-// thread start.
-func (h *Holmes) threadCheckAndDump(threadNum int) {
-	threadOpts := h.opts.GetThreadOpts()
-	if !threadOpts.Enable {
-		return
-	}
-
-	if h.threadCoolDownTime.After(time.Now()) {
-		h.Debugf("[Holmes] thread dump is in cooldown")
-		return
-	}
-	// threadOpts is a struct, no escape.
-	if triggered := h.threadProfile(threadNum, threadOpts); triggered {
-		h.threadCoolDownTime = time.Now().Add(threadOpts.CoolDown)
-		h.threadTriggerCount++
-
-		// optimize: https://github.com/mosn/holmes/issues/84
-		// Thread dump information contains goroutine information
-		// skip goroutine dump
-		h.goroutineCoolDownByThread()
-	}
-}
-
 // The thread dump is triggered while operating goroutine dump CoolDown .
 // Thread dump information contains goroutine information .
 func (h *Holmes) goroutineCoolDownByThread() {
@@ -492,7 +730,7 @@ func (h *Holmes) goroutineCoolDownByThread() {
 		return
 	}
 
-	h.grCoolDownTime = time.Now().Add(grOpts.CoolDown)
+	h.state(goroutine).setCoolDown(h.now().Add(grOpts.CoolDown))
 }
 
 // TODO: better only shrink the threads that are idle.
@@ -505,15 +743,18 @@ func (h *Holmes) startShrinkThread() {
 
 	// check again after the timer triggered
 	if opts.Enable && n > 0 {
-		h.shrinkThreadTriggerCount++
+		now := h.now()
+		h.shrinkState.triggered(now, h.shrinkState.coolDownUntil())
 		h.Infof("[holmes] start to shrink %v threads, now: %v", n, curThreadNum)
 
 		var wg sync.WaitGroup
-		wg.Add(n)
 		for i := 0; i < n; i++ {
 			// avoid close too much thread in batch.
-			time.Sleep(time.Millisecond * 100)
+			if !h.sleep(time.Millisecond * 100) {
+				break
+			}
 
+			wg.Add(1)
 			go func() {
 				defer wg.Done()
 				runtime.LockOSThread()
@@ -525,125 +766,362 @@ func (h *Holmes) startShrinkThread() {
 	}
 }
 
-func (h *Holmes) threadProfile(curThreadNum int, c typeOption) bool {
-	match, reason := matchRule(h.threadStats, curThreadNum, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, NotSupportTypeMaxConfig)
-	if !match {
-		// let user know why this should not dump
-		h.Infof(UniformLogFormat, "NODUMP", check2name[thread],
-			c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
-			h.threadStats.sequentialData(), curThreadNum)
-
-		return false
-	}
+// thread start.
+func (h *Holmes) threadDump(d *DumpContext) error {
+	c := d.Scene.typeOption
 
-	h.Alertf("holmes.thread", UniformLogFormat, "pprof", check2name[thread],
+	h.alertCheck("holmes.thread", d.Check, d.Reason, d.EventID, UniformLogFormat, "pprof", d.Check,
 		c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
-		NotSupportTypeMaxConfig, h.threadStats, curThreadNum)
+		NotSupportTypeMaxConfig, d.stats, d.Scene.CurVal)
 
-	eventID := fmt.Sprintf("thr-%d", h.threadTriggerCount)
 	var buf bytes.Buffer
 
-	_ = pprof.Lookup("threadcreate").WriteTo(&buf, int(h.opts.DumpProfileType)) // nolint: errcheck
-
-	scene := Scene{
-		typeOption: c,
-		CurVal:     curThreadNum,
-		Avg:        h.threadStats.avg(),
-	}
+	_ = pprof.Lookup("threadcreate").WriteTo(&buf, h.opts.profileDebug(thread)) // nolint: errcheck
 
-	h.ReportProfile(type2name[thread], h.writeProfileDataToFile(buf, thread, eventID),
-		reason, eventID, time.Now(), buf.Bytes(), scene)
+	thrFileName, thrData := h.writeProfileDataToFile(buf, thread, d.EventID)
+	d.report(type2name[thread], thrFileName, thrData)
 
-	buf.Reset()
-	_ = pprof.Lookup("goroutine").WriteTo(&buf, int(h.opts.DumpProfileType)) // nolint: errcheck
+	// the bytes of buf is in use by reporter, don't reuse it.
+	var grBuf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&grBuf, h.opts.profileDebug(goroutine)) // nolint: errcheck
 
-	h.ReportProfile(type2name[goroutine], h.writeProfileDataToFile(buf, goroutine, eventID),
-		reason, eventID, time.Now(), buf.Bytes(), scene)
+	grFileName, grData := h.writeProfileDataToFile(grBuf, goroutine, d.EventID)
+	d.report(type2name[goroutine], grFileName, grData)
 
-	return true
+	// optimize: https://github.com/mosn/holmes/issues/84
+	// Thread dump information contains goroutine information
+	// skip goroutine dump
+	h.goroutineCoolDownByThread()
+	return nil
 }
 
 // thread end.
 
 // cpu start.
-func (h *Holmes) cpuCheckAndDump(cpu int) {
-	cpuOpts := h.opts.GetCPUOpts()
-	if !cpuOpts.Enable {
-		return
+func (h *Holmes) cpuDump(d *DumpContext) error {
+	c := d.Scene.typeOption
+
+	// alert before sampling, the hint is attached after the profile is sampled.
+	h.alertCheck("holmes.cpu", d.Check, d.Reason, d.EventID, UniformLogFormat, "pprof dump", d.Check,
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
+		d.stats.sequentialData(), d.Scene.CurVal)
+
+	binFileName, bfCpy, err := h.writeCPUProfileToFile(d.ctx, d.EventID, h.opts.CPUSamplingTime)
+	if err != nil {
+		return err
 	}
 
-	if h.cpuCoolDownTime.After(time.Now()) {
-		h.Debugf("[Holmes] cpu dump is in cooldown")
-		return
+	h.cpuHint(d, bfCpy)
+	d.report(type2name[cpu], binFileName, bfCpy)
+	return nil
+}
+
+// cpuHint logs the hint of the sampled cpu profile, and attaches it to the scene of the report.
+func (h *Holmes) cpuHint(d *DumpContext, data []byte) {
+	d.Scene.Hint = profileHint(data, "cpu", hintTopN)
+	h.logw(LevelInfo, checkFields(d.Check, &d.Reason, d.EventID), "[Holmes] %v dump hint: %v", d.Check, d.Scene.Hint)
+}
+
+// writeCPUProfileToFile collects cpu profile for samplingTime and writes it to file,
+// the profile data is read back for the alert hint, the logger and reporter.
+func (h *Holmes) writeCPUProfileToFile(ctx context.Context, eventID string, samplingTime time.Duration) (string, []byte, error) {
+	bf, binFileName, err := getBinaryFileNameAndCreate(h.opts.DumpPath, cpu, eventID)
+	if err != nil {
+		return binFileName, nil, fmt.Errorf("create cpu profile file failed: %w", err)
 	}
-	// cpuOpts is a struct, no escape.
-	if triggered := h.cpuProfile(cpu, cpuOpts); triggered {
-		h.cpuCoolDownTime = time.Now().Add(cpuOpts.CoolDown)
-		h.cpuTriggerCount++
+	defer bf.Close() // nolint: errcheck
+
+	h.baselines.stopCPU()
+	if err = pprof.StartCPUProfile(bf); err != nil {
+		return binFileName, nil, err
 	}
-}
 
-func (h *Holmes) cpuProfile(curCPUUsage int, c typeOption) bool {
-	match, reason := matchRule(h.cpuStats, curCPUUsage, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, NotSupportTypeMaxConfig)
-	if !match {
-		// let user know why this should not dump
-		h.Infof(UniformLogFormat, "NODUMP", check2name[cpu],
-			c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
-			h.cpuStats.sequentialData(), curCPUUsage)
+	if !h.sleepContext(ctx, samplingTime) {
+		h.Infof("[Holmes] cpu profile sampling is canceled")
+	}
+	pprof.StopCPUProfile()
 
-		return false
+	h.baselines.markDump(h.now())
+
+	bfCpy, err := ioutil.ReadFile(binFileName)
+	if err != nil {
+		return binFileName, nil, fmt.Errorf("read cpu profile file failed: %w", err)
 	}
 
-	h.Alertf("holmes.cpu", UniformLogFormat, "pprof dump", check2name[cpu],
+	if h.opts.DumpToLogger {
+		h.Infof("[Holmes] CPU profile name : ::%v \n%s", binFileName, bfCpy)
+	}
+
+	h.Infof("[Holmes] pprof cpu profile write to file %v successfully", binFileName)
+
+	if cpuBaseline := h.baselines.get(type2name[cpu]); cpuBaseline != nil {
+		h.writeProfileDiff(type2name[cpu], cpuBaseline, bfCpy, eventID, "baseline")
+	}
+	return binFileName, bfCpy, nil
+}
+
+// alloc rate start.
+// collectAllocRate returns the allocation rate in MB/s since the previous collect.
+func (h *Holmes) collectAllocRate(memStats *runtime.MemStats) int {
+	now := h.now()
+	rate := 0
+	if !h.lastAllocTime.IsZero() {
+		rate = calcAllocRate(h.lastTotalAlloc, memStats.TotalAlloc, now.Sub(h.lastAllocTime))
+	}
+	h.lastTotalAlloc, h.lastAllocTime = memStats.TotalAlloc, now
+
+	return rate
+}
+
+// allocRateDump dumps the allocs profile, and a short cpu profile
+// to show the cpu cost by the allocation and GC.
+func (h *Holmes) allocRateDump(d *DumpContext) error {
+	allocOpts := h.opts.GetAllocOpts()
+	c := d.Scene.typeOption
+
+	var buf bytes.Buffer
+	_ = pprof.Lookup("allocs").WriteTo(&buf, h.opts.profileDebug(allocRate)) // nolint: errcheck
+
+	h.alertCheck("holmes.alloc", d.Check, d.Reason, d.EventID, uniformAlertFormat, "pprof", d.Check,
 		c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
-		h.cpuStats.sequentialData(), curCPUUsage)
+		d.stats.sequentialData(), d.Scene.CurVal,
+		profileHint(h.binaryProfile("allocs", buf.Bytes()), "alloc_space", hintTopN))
 
-	bf, binFileName, err := getBinaryFileNameAndCreate(h.opts.DumpPath, cpu, "")
-	if err != nil {
-		h.Errorf("[Holmes] failed to create cpu profile file: %v", err.Error())
-		return false
+	fileName, data := h.writeProfileDataToFile(buf, allocRate, d.EventID)
+	d.report(type2name[allocRate], fileName, data)
+
+	if allocOpts.CPUSamplingTime <= 0 {
+		return nil
 	}
-	defer bf.Close() // nolint: errcheck
 
-	err = pprof.StartCPUProfile(bf)
+	binFileName, bfCpy, err := h.writeCPUProfileToFile(d.ctx, d.EventID, allocOpts.CPUSamplingTime)
 	if err != nil {
-		h.Errorf("[Holmes] failed to profile cpu: %v", err.Error())
-		return false
+		h.Errorf("[Holmes] failed to profile cpu for alloc rate: %v", err.Error())
+		return nil
 	}
 
-	time.Sleep(h.opts.CPUSamplingTime)
-	pprof.StopCPUProfile()
+	d.report(type2name[cpu], binFileName, bfCpy)
+	return nil
+}
 
-	rptOpts, bfCpy := h.opts.GetReporterOpts(), []byte{}
-	if h.opts.DumpToLogger || rptOpts.active == 1 {
-		bfCpy, err = ioutil.ReadFile(binFileName)
-		if err != nil {
-			h.Errorf("encounter error when dumping profile to logger, failed to read cpu profile file: %v", err)
-			return true
+// fd start.
+// collectFDUsage returns the percent of open fds to the soft limit of RLIMIT_NOFILE,
+// it's only collected when the fd dump is enabled.
+func (h *Holmes) collectFDUsage() (fdSample, bool) {
+	fdOpts := h.opts.GetFDOpts()
+	if !fdOpts.Enable {
+		return fdSample{}, false
+	}
+
+	limit, err := getFDLimit()
+	if limit == 0 || err != nil {
+		h.Errorf("[Holmes] get fd limit failed, fd limit: %v, error: %v", limit, err)
+		return fdSample{}, false
+	}
+
+	sample := fdSample{limit: limit}
+	if fdOpts.TCPStates {
+		// track the tcp states in each cycle, the fds and sockets are reused by the dump.
+		if sample.fds, err = listFDs(); err != nil {
+			h.Errorf("[Holmes] failed to list fds: %v", err)
+			return fdSample{}, false
 		}
+		sample.num = len(sample.fds)
+		if sample.sockets, err = getTCPSockets(); err != nil {
+			h.Errorf("[Holmes] failed to read tcp sockets: %v", err)
+		}
+		sample.tcpStates = countTCPStates(sample.fds, sample.sockets)
+		h.Debugf("[Holmes] fd number: %v, tcp states: %v", sample.num, sample.tcpStates)
+	} else if sample.num, err = getFDNum(); err != nil {
+		h.Errorf("[Holmes] get fd number failed: %v", err)
+		return fdSample{}, false
 	}
 
-	if h.opts.DumpToLogger {
-		h.Infof("[Holmes] CPU profile name : " + "::" + binFileName + " \n" + string(bfCpy))
+	sample.usage = int(float64(sample.num) / float64(limit) * 100)
+	return sample, true
+}
+
+// fdNumMatch returns true when the open fd number exceeds the NumAbs.
+func (h *Holmes) fdNumMatch() (bool, ReasonType) {
+	numAbs := h.opts.GetFDOpts().NumAbs
+	if numAbs > 0 && h.cycle.fd.num > numAbs {
+		return true, ReasonFDNumGreaterAbs
 	}
+	return false, ReasonCurlLessMin
+}
 
-	scene := Scene{
-		typeOption: c,
-		CurVal:     curCPUUsage,
-		Avg:        h.cpuStats.avg(),
+// fdDump dumps the goroutine profile, and the open fds with their targets,
+// since connection leaks usually show up as fd exhaustion.
+func (h *Holmes) fdDump(d *DumpContext) error {
+	fdOpts := h.opts.GetFDOpts()
+	c := d.Scene.typeOption
+	// the fds and sockets of the cycle, when the tcp states are tracked.
+	sample := h.cycle.fd
+	d.Scene.TCPStates = sample.tcpStates
+
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, h.opts.profileDebug(goroutine)) // nolint: errcheck
+
+	h.alertCheck("holmes.fd", d.Check, d.Reason, d.EventID, uniformAlertFormat, "pprof", d.Check,
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
+		d.stats.sequentialData(), d.Scene.CurVal,
+		goroutineHint(h.binaryProfile("goroutine", buf.Bytes()), hintTopN))
+
+	grFileName, grData := h.writeProfileDataToFile(buf, goroutine, d.EventID)
+	d.report(type2name[goroutine], grFileName, grData)
+
+	fds, sockets := sample.fds, sample.sockets
+	if !fdOpts.TCPStates || fds == nil {
+		var err error
+		if fds, err = listFDs(); err != nil {
+			h.Errorf("[Holmes] failed to list fds: %v", err)
+			return nil
+		}
 	}
 
-	if rptOpts.active == 1 {
-		h.ReportProfile(type2name[cpu], binFileName,
-			reason, "", time.Now(), bfCpy, scene)
+	buf = formatFDs(fds, sample.limit, sockets)
+	fdFileName, fdData := h.writeProfileDataToFile(buf, fd, d.EventID)
+	d.report(type2name[fd], fdFileName, fdData)
+	return nil
+}
+
+// memory pressure start.
+// memPressureSample is the cgroup memory stats collected in a cycle.
+type memPressureSample struct {
+	cgroupMemStats
+	// working set in percent of the memory limit.
+	usage int
+	// whether any counter of memory events increased since previous collect.
+	eventsIncreased bool
+}
+
+// collectMemPressure collects the cgroup memory stats, it's only collected when the memory pressure dump is enabled.
+func (h *Holmes) collectMemPressure() (memPressureSample, bool) {
+	if !h.opts.GetMemPressureOpts().Enable {
+		return memPressureSample{}, false
 	}
 
-	return true
+	stats, err := getCGroupMemStats()
+	if err != nil {
+		h.Errorf("[Holmes] get cgroup memory stats failed: %v", err)
+		return memPressureSample{}, false
+	}
+
+	// there is no limit in cgroup, use the memory limit of holmes.
+	if stats.Max == 0 {
+		if stats.Max, err = h.getMemoryLimit(); stats.Max == 0 || err != nil {
+			h.Errorf("[Holmes] get memory limit failed, memory limit: %v, error: %v", stats.Max, err)
+			return memPressureSample{}, false
+		}
+	}
+
+	sample := memPressureSample{
+		cgroupMemStats: stats,
+		usage:          stats.workingSetPercent(),
+	}
+	if h.lastMemEvents != nil {
+		sample.eventsIncreased = stats.Events.increasedSince(*h.lastMemEvents)
+	}
+	h.lastMemEvents = &stats.Events
+
+	return sample, true
 }
 
-func (h *Holmes) gcHeapCheckLoop(ch chan struct{}) {
-	for range ch {
-		h.gcHeapCheckAndDump()
+// memPressureMatch matches the memory pressure and events besides the working set rules.
+func (h *Holmes) memPressureMatch() (bool, ReasonType) {
+	pressureAbs := h.opts.GetMemPressureOpts().PressureAbs
+	sample := h.cycle.memPressure
+	if pressureAbs > 0 && sample.Pressure.SomeAvg10 > float64(pressureAbs) {
+		return true, ReasonPressureGreaterAbs
+	}
+	if sample.eventsIncreased {
+		return true, ReasonMemoryEvents
+	}
+	return false, ReasonCurlLessMin
+}
+
+// memPressureDump dumps the heap and goroutine profile when the container is close to be OOM killed.
+func (h *Holmes) memPressureDump(d *DumpContext) error {
+	sample := h.cycle.memPressure
+	c := d.Scene.typeOption
+
+	var buf bytes.Buffer
+	_ = pprof.Lookup("heap").WriteTo(&buf, h.opts.profileDebug(mem)) // nolint: errcheck
+
+	h.alertCheck("holmes.mempressure", d.Check, d.Reason, d.EventID, uniformAlertFormat, "pprof", d.Check,
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
+		d.stats.sequentialData(), d.Scene.CurVal,
+		profileHint(h.binaryProfile("heap", buf.Bytes()), "inuse_space", hintTopN))
+	h.Infof("[Holmes] memory current: %v, max: %v, working set: %v, pressure: %+v, events: %+v",
+		sample.Current, sample.Max, sample.workingSet(), sample.Pressure, sample.Events)
+
+	memFileName, memData := h.writeProfileDataToFile(buf, mem, d.EventID)
+	d.report(type2name[mem], memFileName, memData)
+
+	// the bytes of buf is in use by reporter, don't reuse it.
+	var grBuf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&grBuf, h.opts.profileDebug(goroutine)) // nolint: errcheck
+
+	grFileName, grData := h.writeProfileDataToFile(grBuf, goroutine, d.EventID)
+	d.report(type2name[goroutine], grFileName, grData)
+	return nil
+}
+
+// cpu throttle start.
+// collectCPUThrottling returns the cpu throttling stats since previous collect,
+// it's only collected when the cpu throttle dump is enabled.
+func (h *Holmes) collectCPUThrottling() (CPUThrottlingStats, bool) {
+	if !h.opts.GetCPUThrottleOpts().Enable {
+		h.lastCPUThrottling = nil
+		return CPUThrottlingStats{}, false
+	}
+
+	stats, err := getCGroupCPUThrottlingStats()
+	if err != nil {
+		h.Errorf("[Holmes] get cgroup cpu throttling stats failed: %v", err)
+		return CPUThrottlingStats{}, false
+	}
+
+	prev := h.lastCPUThrottling
+	h.lastCPUThrottling = &stats
+	if prev == nil {
+		// the first collect, no delta yet.
+		return CPUThrottlingStats{}, false
+	}
+	return stats.sub(*prev), true
+}
+
+// cpuThrottleDump dumps the cpu profile to show what burned the cpu quota.
+func (h *Holmes) cpuThrottleDump(d *DumpContext) error {
+	throttling := h.cycle.throttling
+	c := d.Scene.typeOption
+
+	// alert before sampling, the hint is attached after the profile is sampled.
+	h.alertCheck("holmes.cputhrottle", d.Check, d.Reason, d.EventID, UniformLogFormat, "pprof dump", d.Check,
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs, NotSupportTypeMaxConfig,
+		d.stats.sequentialData(), d.Scene.CurVal)
+	h.Infof("[Holmes] cpu throttled periods: %v/%v, throttled time: %v",
+		throttling.Throttled, throttling.Periods, throttling.ThrottledTime)
+
+	binFileName, bfCpy, err := h.writeCPUProfileToFile(d.ctx, d.EventID, h.opts.CPUSamplingTime)
+	if err != nil {
+		return err
+	}
+
+	h.cpuHint(d, bfCpy)
+	d.Scene.CPUThrottling = &throttling
+	d.report(type2name[cpu], binFileName, bfCpy)
+	return nil
+}
+
+func (h *Holmes) gcHeapCheckLoop(ctx context.Context, ch chan struct{}) {
+	for {
+		select {
+		case <-ch:
+			h.gcHeapCheckAndDump()
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -657,11 +1135,9 @@ func (h *Holmes) gcHeapCheckAndDump() {
 	memStats := new(runtime.MemStats)
 	runtime.ReadMemStats(memStats)
 
-	// TODO: we can only use NextGC for now since runtime haven't expose heapmarked yet
-	// and we hard code the gcPercent is 100 here.
-	// may introduce a new API debug.GCHeapMarked? it can also has better performance(no STW).
-	nextGC := memStats.NextGC
-	prevGC := nextGC / 2 //nolint:gomnd
+	// the heap marked by the previous GC cycle,
+	// it respects the GOGC and the soft memory limit.
+	prevGC := getHeapMarked(memStats)
 
 	memoryLimit, err := h.getMemoryLimit()
 	if memoryLimit == 0 || err != nil {
@@ -670,7 +1146,7 @@ func (h *Holmes) gcHeapCheckAndDump() {
 	}
 
 	ratio := int(100 * float64(prevGC) / float64(memoryLimit))
-	h.gcHeapStats.push(ratio)
+	h.state(gcHeap).push(ratio)
 
 	h.gcCycleCount++
 	if h.gcCycleCount < minCollectCyclesBeforeDumpStart {
@@ -680,8 +1156,9 @@ func (h *Holmes) gcHeapCheckAndDump() {
 		return
 	}
 
-	if h.gcHeapCoolDownTime.After(time.Now()) {
-		h.Debugf("[Holmes] GC heap dump is in cooldown")
+	state := h.state(gcHeap)
+	if until := state.coolDownUntil(); until.After(h.now()) {
+		h.logCoolDown(check2name[gcHeap], ratio, until)
 		return
 	}
 
@@ -689,8 +1166,8 @@ func (h *Holmes) gcHeapCheckAndDump() {
 		if h.gcHeapTriggered {
 			// already dump twice, mark it false
 			h.gcHeapTriggered = false
-			h.gcHeapCoolDownTime = time.Now().Add(gcHeapOpts.CoolDown)
-			h.gcHeapTriggerCount++
+			now := h.now()
+			state.triggered(now, now.Add(gcHeapOpts.CoolDown))
 		} else {
 			// force dump next time
 			h.gcHeapTriggered = true
@@ -719,6 +1196,12 @@ func (h *Holmes) getMemoryLimit() (uint64, error) {
 		return h.opts.memoryLimit, nil
 	}
 
+	if h.opts.UseGoMemLimit {
+		if limit := getGoMemoryLimit(); limit > 0 {
+			return limit, nil
+		}
+	}
+
 	if h.opts.UseCGroup {
 		return getCGroupMemoryLimit()
 	}
@@ -730,51 +1213,163 @@ func (h *Holmes) getMemoryLimit() (uint64, error) {
 // since the current memory profile will be merged after next GC cycle.
 // And we assume the finalizer will be called before next GC cycle(it will be usually).
 func (h *Holmes) gcHeapProfile(gc int, force bool, c typeOption) bool {
-	match, reason := matchRule(h.gcHeapStats, gc, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, NotSupportTypeMaxConfig)
+	stats := h.state(gcHeap).history()
+	match, reason := matchRule(stats, gc, c.TriggerMin, c.TriggerAbs, c.TriggerDiff, NotSupportTypeMaxConfig)
 	if !force && !match {
 		// let user know why this should not dump
-		h.Infof(UniformLogFormat, "NODUMP", check2name[gcHeap],
-			c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
-			NotSupportTypeMaxConfig,
-			h.gcHeapStats.sequentialData(), gc)
+		h.logNoDump(check2name[gcHeap], c, NotSupportTypeMaxConfig, stats, gc, reason)
 
 		return false
 	}
 
-	h.Alertf("holmes.gcheap", UniformLogFormat, "pprof", check2name[gcHeap],
-		c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
-		NotSupportTypeMaxConfig, h.gcHeapStats, gc)
-
-	// gcHeapTriggerCount only increased after got both two profiles
-	eventID := fmt.Sprintf("heap-%d", h.gcHeapTriggerCount)
+	// the trigger count only increased after got both two profiles
+	eventID := fmt.Sprintf("heap-%d", h.state(gcHeap).count())
 
 	var buf bytes.Buffer
-	_ = pprof.Lookup("heap").WriteTo(&buf, int(h.opts.DumpProfileType)) // nolint: errcheck
+	_ = pprof.Lookup("heap").WriteTo(&buf, h.opts.profileDebug(gcHeap)) // nolint: errcheck
+
+	h.alertCheck("holmes.gcheap", check2name[gcHeap], reason, eventID, uniformAlertFormat, "pprof", check2name[gcHeap],
+		c.TriggerMin, c.TriggerDiff, c.TriggerAbs,
+		NotSupportTypeMaxConfig, stats, gc,
+		profileHint(h.binaryProfile("heap", buf.Bytes()), "inuse_space", hintTopN))
 
 	scene := Scene{
 		typeOption: c,
+		Check:      check2name[gcHeap],
 		CurVal:     gc,
-		Avg:        h.gcHeapStats.avg(),
+		Avg:        stats.avg(),
+	}
+
+	fileName, data := h.writeProfileDataToFile(buf, gcHeap, eventID)
+	h.ReportProfile(type2name[gcHeap], fileName, reason, eventID, h.now(), data, scene)
+
+	// the cooldown starts after the second dump.
+	coolDown := time.Duration(0)
+	if force {
+		coolDown = c.CoolDown
 	}
+	h.logDump(check2name[gcHeap], c, NotSupportTypeMaxConfig, stats, gc, reason, coolDown, eventID, fileName)
 
-	h.ReportProfile(type2name[gcHeap], h.writeProfileDataToFile(buf, gcHeap, eventID),
-		reason, eventID, time.Now(), buf.Bytes(), scene)
+	if h.opts.HeapDiffTopN > 0 {
+		cur := h.binaryProfile("heap", buf.Bytes())
+		if !force {
+			// the first dump, diff it with the next one.
+			h.gcHeapPrevProfile = cur
+		} else if h.gcHeapPrevProfile != nil {
+			h.writeProfileDiff("heap", h.gcHeapPrevProfile, cur, eventID, "prev")
+			h.gcHeapPrevProfile = nil
+		}
+	}
 	return true
 }
 
-func (h *Holmes) writeProfileDataToFile(data bytes.Buffer, dumpType configureType, eventID string) string {
-	fileName, err := writeFile(data, dumpType, h.opts.DumpOptions, eventID)
+// goroutine leak start.
+func (h *Holmes) goroutineLeakCheckAndDump() {
+	grLeakOpts := h.opts.GetGrLeakOpts()
+	if !grLeakOpts.Enable {
+		return
+	}
+
+	now := h.now()
+	if now.Sub(h.lastGrLeakSnapshot) < grLeakOpts.Interval {
+		return
+	}
+	h.lastGrLeakSnapshot = now
+
+	// always take the snapshot even in cooldown, to keep the growth history continuous.
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 2) // nolint: errcheck
+	groups, total := inspect.SummarizeGoroutineDump(buf.Bytes(), 0)
+	leaks := h.grLeakDetector.observe(groups, grLeakOpts.GrowthCycles, grLeakOpts.MinWait)
+
+	state := h.state(goroutineLeak)
+	if until := state.coolDownUntil(); until.After(now) {
+		h.logCoolDown(check2name[goroutineLeak], total, until)
+		return
+	}
+	if len(leaks) == 0 {
+		h.Debugf("[Holmes] no goroutine leak found in %v goroutine groups", len(groups))
+		return
+	}
+
+	h.goroutineLeakProfile(leaks, total, buf)
+	now = h.now()
+	state.triggered(now, now.Add(grLeakOpts.CoolDown))
+}
+
+// goroutineLeakProfile writes the leaking goroutine groups and the goroutine dump with debug=2.
+func (h *Holmes) goroutineLeakProfile(leaks []GoroutineGroup, total int, buf bytes.Buffer) {
+	eventID := fmt.Sprintf("grleak-%d", h.state(goroutineLeak).count())
+	for _, g := range leaks {
+		h.alertCheck("holmes.goroutineleak", check2name[goroutineLeak], ReasonGoroutineLeak, eventID, "[Holmes] goroutine leak, %v goroutines [%v, wait %v - %v] created by %v, stack: %v",
+			g.Count, g.State, g.MinWait, g.MaxWait, g.CreatedBy, g.Stack)
+	}
+
+	leakFileName, _ := h.writeProfileDataToFile(inspect.FormatGoroutineGroups(leaks, total), goroutineLeak, eventID)
+
+	scene := Scene{
+		Check:           check2name[goroutineLeak],
+		CurVal:          leaks[0].Count,
+		GoroutineGroups: leaks,
+	}
+
+	grFileName, grData := h.writeProfileDataToFile(buf, goroutine, eventID)
+	h.ReportProfile(type2name[goroutine], grFileName, ReasonGoroutineLeak, eventID, h.now(), grData, scene)
+
+	e := CheckEvent{
+		Time:     h.now(),
+		Check:    check2name[goroutineLeak],
+		Decision: DecisionDump,
+		Reason:   ReasonGoroutineLeak.String(),
+		Current:  total,
+		EventID:  eventID,
+		Files:    []string{leakFileName, grFileName},
+	}
+	if coolDown := h.opts.GetGrLeakOpts().CoolDown; coolDown > 0 {
+		until := e.Time.Add(coolDown)
+		e.CoolDownUntil = &until
+	}
+	h.logCheckEvent(e)
+}
+
+// writeGoroutineSummary dumps goroutines with debug=2, and writes the top n groups
+// of goroutines with identical stack and state to file.
+func (h *Holmes) writeGoroutineSummary(n int, eventID string) []GoroutineGroup {
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 2) // nolint: errcheck
+
+	groups, total := inspect.SummarizeGoroutineDump(buf.Bytes(), n)
+	h.writeProfileDataToFile(inspect.FormatGoroutineGroups(groups, total), goroutineSummary, eventID)
+	return groups
+}
+
+func (h *Holmes) writeProfileDataToFile(data bytes.Buffer, dumpType configureType, eventID string) (string, []byte) {
+	return h.writeProfileData(data, dumpType, eventID, false)
+}
+
+// writeProfileData writes the profile like writeProfileDataToFile, and flushes it to disk when fsync is true.
+// it returns the file name and the bytes written to it, which are logged and reported, e.g. the top n report
+// rendered from the binary profile in text mode, so the report is the same as the file which could be resent.
+func (h *Holmes) writeProfileData(data bytes.Buffer, dumpType configureType, eventID string, fsync bool) (string, []byte) {
+	written := h.opts.DumpOptions.renderProfile(data, dumpType)
+	fileName, err := writeFile(written, dumpType, h.opts.DumpOptions, eventID, fsync)
 	if err != nil {
 		h.Errorf("failed to write profile to file(%v), err: %s", fileName, err.Error())
-		return ""
+		return "", nil
 	}
 
 	if h.opts.DumpOptions.DumpToLogger {
-		h.Infof(fmt.Sprintf("[Holmes] %v profile: \n", check2name[dumpType]) + data.String())
+		h.Infof("[Holmes] %v profile: \n%s", check2name[dumpType], written)
 	}
 
 	h.Infof("[Holmes] pprof %v profile write to file %v successfully", check2name[dumpType], fileName)
-	return fileName
+	h.baselines.markDump(h.now())
+
+	switch dumpType {
+	case mem, gcHeap, goroutine:
+		h.diffWithBaseline(type2name[dumpType], data.Bytes(), eventID)
+	}
+	return fileName, written
 }
 
 func (h *Holmes) initEnvironment() {
@@ -784,11 +1379,23 @@ func (h *Holmes) initEnvironment() {
 	} else {
 		h.Infof("[Holmes] use the default memory percent calculated by gopsutil")
 	}
+
+	if limit := getGoMemoryLimit(); limit > 0 {
+		if h.opts.UseGoMemLimit {
+			h.Infof("[Holmes] use the go memory limit %v as the memory limit", limit)
+		} else {
+			h.Infof("[Holmes] go memory limit %v is set, you may use WithGoMemLimit to take it as the memory limit", limit)
+		}
+	}
+
+	if h.opts.GetCrashOutput() {
+		h.initCrashOutput()
+	}
 }
 
 func (h *Holmes) EnableDump(curCPU int) (err error) {
-	if h.opts.CPUMaxPercent != 0 && curCPU >= h.opts.CPUMaxPercent {
-		return fmt.Errorf("current cpu percent [%v] is greater than the CPUMaxPercent [%v]", curCPU, h.opts.CPUMaxPercent)
+	if max := h.opts.GetCPUMaxPercent(); max != 0 && curCPU >= max {
+		return fmt.Errorf("current cpu percent [%v] is greater than the CPUMaxPercent [%v]", curCPU, max)
 	}
 	return nil
 }
@@ -806,17 +1413,24 @@ func (h *Holmes) Set(opts ...Option) error {
 	return nil
 }
 
+// DisableProfileReporter stops reporting the dumps, it's locked like Set since the reporter options are copied under the lock.
 func (h *Holmes) DisableProfileReporter() {
+	h.opts.L.Lock()
+	defer h.opts.L.Unlock()
 	atomic.StoreInt32(&h.opts.rptOpts.active, 0)
 }
 
 func (h *Holmes) EnableProfileReporter() {
-	opt := h.opts.GetReporterOpts()
-	if opt.reporter == nil {
+	h.opts.L.Lock()
+	enabled := h.opts.rptOpts.reporter != nil
+	if enabled {
+		atomic.StoreInt32(&h.opts.rptOpts.active, 1)
+	}
+	h.opts.L.Unlock()
+
+	if !enabled {
 		h.Infof("failed to enable profile reporter since reporter is empty")
-		return
 	}
-	atomic.StoreInt32(&h.opts.rptOpts.active, 1)
 }
 
 func (h *Holmes) ReportProfile(pType string, filename string, reason ReasonType, eventID string, sampleTime time.Time, pprofBytes []byte, scene Scene) {
@@ -850,38 +1464,51 @@ func (h *Holmes) ReportProfile(pType string, filename string, reason ReasonType,
 		Scene:      scene,
 	}
 
-	// read channel should be atomic.
+	// send under the lock, since the channel is closed by stop.
+	if !h.sendReport(msg) {
+		h.Warnf("reporter channel is full, will ignore it")
+	}
+}
+
+// sendReport queues the report without blocking, returns false when the channel is full.
+func (h *Holmes) sendReport(msg rptEvent) bool {
+	h.Lock()
+	defer h.Unlock()
+
 	ch := h.rptEventsCh
 	if ch == nil {
-		return
+		// stopped
+		return true
 	}
-	// Notice: here may be a litte race, will panic when ch is closed now.
-	// we just leave it since it is very small and there is a recover.
 	select {
 	case ch <- msg:
+		return true
 	default:
-		h.Warnf("reporter channel is full, will ignore it")
+		return false
 	}
 }
 
-// startReporter starts a background goroutine to consume event channel,
-// and finish it at after receive from cancel channel.
+// startReporter consumes the event channel and sends the reports,
+// until the channel is closed by stop and drained.
 func (h *Holmes) startReporter(ch chan rptEvent) {
-	go func() {
-		for evt := range ch {
-			opts := h.opts.GetReporterOpts()
-			if opts.reporter == nil {
-				h.Infof("reporter is nil, please initial it before startReporter")
-				// drop the event
-				continue
-			}
+	for evt := range ch {
+		if atomic.LoadInt32(&h.dropReports) == 1 {
+			h.Warnf("[Holmes] dump %v is not reported since shutdown timed out, it could be resent by the holmes command", evt.FileName)
+			continue
+		}
 
-			// It's supposed to be sending judgment, isn't it?
-			err := opts.reporter.Report(evt.PType, evt.FileName, evt.Reason, evt.EventID, evt.SampleTime, evt.PprofBytes, evt.Scene) // nolint: errcheck
-			if err != nil {
-				h.Infof("reporter err:%v", err)
+		opts := h.opts.GetReporterOpts()
+		if opts.reporter == nil {
+			h.Infof("reporter is nil, please initial it before startReporter")
+			// drop the event
+			continue
+		}
+
+		// It's supposed to be sending judgment, isn't it?
+		err := opts.reporter.Report(evt.PType, evt.FileName, evt.Reason, evt.EventID, evt.SampleTime, evt.PprofBytes, evt.Scene) // nolint: errcheck
+		if err != nil {
+			h.Infof("reporter err:%v", err)
 
-			}
 		}
-	}()
+	}
 }
@@ -18,11 +18,16 @@
 package holmes
 
 import (
+	"context"
+	"io/ioutil"
 	"log"
 	"os"
 	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 var h *Holmes
@@ -43,14 +48,14 @@ func TestMain(m *testing.M) {
 
 // -gcflags=all=-l
 func TestResetCollectInterval(t *testing.T) {
-	before := h.collectCount
+	before := atomic.LoadInt64(&h.collectCount)
 	go func() {
 		h.Set(WithCollectInterval("2s"))       //nolint:errcheck
 		defer h.Set(WithCollectInterval("1s")) //nolint:errcheck
 		time.Sleep(6 * time.Second)
 		// if collect interval not change, collectCount would increase 5 at least
-		if h.collectCount-before >= 5 {
-			log.Fatalf("fail, before %v, now %v", before, h.collectCount)
+		if now := atomic.LoadInt64(&h.collectCount); now-before >= 5 {
+			log.Fatalf("fail, before %v, now %v", before, now)
 		}
 	}()
 	time.Sleep(8 * time.Second)
@@ -60,7 +65,7 @@ func TestSetGrOpts(t *testing.T) {
 	// decrease min trigger, if our set api is effective,
 	// gr profile would be trigger and grCoolDown increase.
 	min, diff, abs := 3, 10, 1
-	before := h.grCoolDownTime
+	before := h.state(goroutine).coolDownUntil()
 
 	err := h.Set(
 		WithGoroutineDump(min, diff, abs, 90, time.Minute))
@@ -69,7 +74,7 @@ func TestSetGrOpts(t *testing.T) {
 	}
 
 	time.Sleep(5 * time.Second)
-	if before.Equal(h.grCoolDownTime) {
+	if before.Equal(h.state(goroutine).coolDownUntil()) {
 		log.Fatalf("fail")
 	}
 }
@@ -119,7 +124,7 @@ func createThread(n int, blockTime time.Duration) {
 }
 
 func TestWithShrinkThread(t *testing.T) {
-	before := h.shrinkThreadTriggerCount
+	before := h.shrinkState.count()
 
 	err := h.Set(
 		// delay 5 seconds, after the 50 threads unlocked
@@ -145,8 +150,8 @@ func TestWithShrinkThread(t *testing.T) {
 
 	time.Sleep(10 * time.Second)
 
-	if before+1 != h.shrinkThreadTriggerCount {
-		log.Fatalf("shrink thread not triggered, before: %v, now: %v", before, h.shrinkThreadTriggerCount)
+	if now := h.shrinkState.count(); before+1 != now {
+		log.Fatalf("shrink thread not triggered, before: %v, now: %v", before, now)
 	}
 
 	threadNum3 := getThreadNum()
@@ -156,3 +161,77 @@ func TestWithShrinkThread(t *testing.T) {
 
 	h.DisableShrinkThread()
 }
+
+// blockingReporter blocks every report until it's released.
+type blockingReporter struct {
+	release  chan struct{}
+	reported int32
+}
+
+func (r *blockingReporter) Report(pType string, filename string, reason ReasonType, eventID string,
+	sampleTime time.Time, pprofBytes []byte, scene Scene) error {
+	<-r.release
+	atomic.AddInt32(&r.reported, 1)
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-shutdown")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	hm, err := New(WithCollectInterval("1h"), WithDumpPath(dir))
+	assert.Nil(t, err)
+	hm.Start()
+
+	// the cpu sampling is canceled.
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		_, _, _ = hm.writeCPUProfileToFile(hm.runContext(), "shutdown", time.Minute) // nolint: errcheck
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// the dump loop waiting for the next tick exits.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, hm.Shutdown(ctx))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hm.stopped))
+
+	select {
+	case <-sampled:
+	case <-time.After(time.Second):
+		t.Fatal("cpu sampling is not canceled")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	r := &blockingReporter{release: make(chan struct{})}
+	hm, err := New(WithCollectInterval("1h"), WithProfileReporter(r))
+	assert.Nil(t, err)
+	hm.Start()
+
+	hm.ReportProfile("goroutine", "goroutine.1.log", ReasonCurGreaterAbs, "", time.Now(), nil, Scene{})
+	hm.ReportProfile("goroutine", "goroutine.2.log", ReasonCurGreaterAbs, "", time.Now(), nil, Scene{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, hm.Shutdown(ctx))
+
+	// the report in flight is finished, and the queued one is dropped.
+	close(r.release)
+	assert.Nil(t, hm.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r.reported))
+}
+
+func TestStartContext(t *testing.T) {
+	hm, err := New(WithCollectInterval("1h"))
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hm.StartContext(ctx)
+	cancel()
+
+	assert.Nil(t, hm.Shutdown(context.Background()))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&hm.stopped))
+}
@@ -0,0 +1,284 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package holmestest provides the fakes to test holmes and its integration deterministically,
+// without real sleeps and real process stats.
+package holmestest
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/holmes"
+)
+
+// Clock is a fake holmes.Clock, the time only moves when Advance is called.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*ticker
+	timers  []*timer
+	// the tick which is waiting to be received, and the time before it.
+	pending *ticker
+	prev    time.Time
+}
+
+// NewClock returns a Clock starting at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker returns a ticker which ticks when the clock is advanced every d.
+func (c *Clock) NewTicker(d time.Duration) holmes.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &ticker{
+		c:       make(chan time.Time, 1),
+		stopped: make(chan struct{}),
+		period:  d,
+		next:    c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// NewTimer returns a timer which fires when the clock is advanced by d.
+// the timer created while a tick is waiting to be received starts from the time before the tick,
+// since it's created by the receiver still running for the previous tick.
+func (c *Clock) NewTimer(d time.Duration) holmes.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	start := c.now
+	if c.pending != nil && len(c.pending.c) > 0 {
+		start = c.prev
+	}
+	t := &timer{
+		clock: c,
+		c:     make(chan time.Time, 1),
+		when:  start.Add(d),
+	}
+	if d <= 0 {
+		t.fire()
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, and delivers the ticks and fires the timers which are due in order.
+// unlike time.Ticker, the ticks are never dropped: it blocks until each tick is received or the ticker is stopped,
+// so that the dump loop of holmes runs a cycle in lockstep with each tick.
+// while a tick is waiting to be received, the timers which are due before the end fire first,
+// since the receiver may be waiting for them, e.g. the dump loop sampling the cpu profile.
+// Notice: the cycle of the last tick may be still running when it returns, advance once more to wait for it.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		t := c.nextTicker(end)
+		until := end
+		if t != nil {
+			until = t.next
+		}
+		if c.fireTimer(until) {
+			c.mu.Unlock()
+			continue
+		}
+		if t == nil {
+			c.now = end
+			c.mu.Unlock()
+			return
+		}
+		tick := t.next
+		t.next = t.next.Add(t.period)
+		c.pending, c.prev = t, c.now
+		c.now = tick
+		t.c <- tick
+		c.mu.Unlock()
+
+		c.waitReceived(t, end)
+	}
+}
+
+// waitReceived waits until the tick of t is received or t is stopped,
+// and fires the timers which are due before end meanwhile.
+func (c *Clock) waitReceived(t *ticker, end time.Time) {
+	for {
+		c.mu.Lock()
+		select {
+		case <-t.stopped:
+			c.pending = nil
+			c.mu.Unlock()
+			return
+		default:
+		}
+		if len(t.c) == 0 {
+			c.pending = nil
+			c.mu.Unlock()
+			return
+		}
+		fired := c.fireTimer(end)
+		c.mu.Unlock()
+
+		if !fired {
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+}
+
+// WaitTicker waits until a running ticker is created or timeout, e.g. by the dump loop after holmes starts,
+// returns whether there is a running ticker.
+func (c *Clock) WaitTicker(timeout time.Duration) bool {
+	return c.wait(timeout, func() bool {
+		return c.nextTicker(maxTime) != nil
+	})
+}
+
+// WaitTimer waits until a running timer is created or timeout, e.g. by Dump sampling the cpu profile,
+// returns whether there is a running timer.
+func (c *Clock) WaitTimer(timeout time.Duration) bool {
+	return c.wait(timeout, func() bool {
+		return len(c.runningTimers()) > 0
+	})
+}
+
+// wait polls until running returns true with the clock locked, or timeout.
+func (c *Clock) wait(timeout time.Duration, running func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		c.mu.Lock()
+		ok := running()
+		c.mu.Unlock()
+		if ok {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// maxTime is the time after any tick.
+var maxTime = time.Unix(1<<62, 0)
+
+// fireTimer fires the earliest running timer which is due not after until, returns whether there is one.
+func (c *Clock) fireTimer(until time.Time) bool {
+	var next *timer
+	for _, t := range c.runningTimers() {
+		if !t.when.After(until) && (next == nil || t.when.Before(next.when)) {
+			next = t
+		}
+	}
+	if next == nil {
+		return false
+	}
+	if next.when.After(c.now) {
+		c.now = next.when
+	}
+	next.fire()
+	return true
+}
+
+// runningTimers removes the fired and stopped timers, returns the running ones.
+func (c *Clock) runningTimers() []*timer {
+	running := c.timers[:0]
+	for _, t := range c.timers {
+		if !t.stopped {
+			running = append(running, t)
+		}
+	}
+	c.timers = running
+	return running
+}
+
+// nextTicker returns the running ticker whose next tick is the earliest one not after end.
+func (c *Clock) nextTicker(end time.Time) *ticker {
+	var next *ticker
+	running := c.tickers[:0]
+	for _, t := range c.tickers {
+		select {
+		case <-t.stopped:
+			continue
+		default:
+		}
+		running = append(running, t)
+		if !t.next.After(end) && (next == nil || t.next.Before(next.next)) {
+			next = t
+		}
+	}
+	c.tickers = running
+	return next
+}
+
+type ticker struct {
+	c        chan time.Time
+	stopped  chan struct{}
+	stopOnce sync.Once
+	period   time.Duration
+	next     time.Time
+}
+
+func (t *ticker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *ticker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopped)
+	})
+}
+
+type timer struct {
+	clock   *Clock
+	c       chan time.Time
+	when    time.Time
+	stopped bool
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+// fire is called with the clock locked, the timer is removed from the clock by runningTimers later.
+func (t *timer) fire() {
+	t.stopped = true
+	t.c <- t.when
+}
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	if t.stopped {
+		return false
+	}
+	t.stopped = true
+	return true
+}
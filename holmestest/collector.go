@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmestest
+
+import (
+	"sync"
+
+	"mosn.io/holmes"
+)
+
+// Collector is a fake holmes.Collector returning the scripted samples in order,
+// the last sample is repeated when all samples are collected.
+type Collector struct {
+	mu      sync.Mutex
+	samples []holmes.MetricSample
+	last    holmes.MetricSample
+	count   int
+}
+
+// NewCollector returns a Collector with the scripted samples.
+func NewCollector(samples ...holmes.MetricSample) *Collector {
+	return &Collector{samples: samples}
+}
+
+// Push appends the samples to collect.
+func (c *Collector) Push(samples ...holmes.MetricSample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples = append(c.samples, samples...)
+}
+
+// Collect returns the next scripted sample, the time of the sample is ignored.
+func (c *Collector) Collect(cpuCore float64, memoryLimit uint64) (int, int, int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.samples) > 0 {
+		c.last = c.samples[0]
+		c.samples = c.samples[1:]
+	}
+	c.count++
+	return c.last.CPU, c.last.Mem, c.last.Goroutine, c.last.Thread, nil
+}
+
+// Count returns the number of collects.
+func (c *Collector) Count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
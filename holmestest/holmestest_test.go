@@ -0,0 +1,410 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmestest
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/holmes"
+)
+
+func TestClock(t *testing.T) {
+	c := NewClock(testStart)
+	tk := c.NewTicker(5 * time.Second)
+
+	var ticks []time.Time
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			ticks = append(ticks, <-tk.C())
+		}
+		tk.Stop()
+	}()
+
+	c.Advance(4 * time.Second)
+	assert.Equal(t, testStart.Add(4*time.Second), c.Now())
+	c.Advance(20 * time.Second)
+	<-done
+	assert.Equal(t, []time.Time{testStart.Add(5 * time.Second), testStart.Add(10 * time.Second), testStart.Add(15 * time.Second)}, ticks)
+	assert.Equal(t, testStart.Add(24*time.Second), c.Now())
+	assert.False(t, c.WaitTicker(time.Millisecond))
+}
+
+func TestClockTimer(t *testing.T) {
+	c := NewClock(testStart)
+	tk := c.NewTicker(5 * time.Second)
+	defer tk.Stop()
+	stopped := c.NewTimer(time.Second)
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	// the timer created after the tick of 5s is received starts from it, even the tick of 10s is waiting,
+	// and it fires before the tick of 10s is received, since the receiver is waiting for it.
+	fired := make(chan time.Time, 1)
+	go func() {
+		<-tk.C()
+		tm := c.NewTimer(5 * time.Second)
+		fired <- <-tm.C()
+		<-tk.C()
+	}()
+	c.Advance(10 * time.Second)
+	assert.Equal(t, testStart.Add(10*time.Second), <-fired)
+	assert.Equal(t, testStart.Add(10*time.Second), c.Now())
+	assert.False(t, c.WaitTimer(time.Millisecond))
+
+	// the timer created after advanced starts from now.
+	tm := c.NewTimer(3 * time.Second)
+	assert.True(t, c.WaitTimer(time.Millisecond))
+	c.Advance(2 * time.Second)
+	select {
+	case <-tm.C():
+		t.Fatal("the timer fired early")
+	default:
+	}
+	c.Advance(time.Second)
+	assert.Equal(t, testStart.Add(13*time.Second), <-tm.C())
+	assert.False(t, tm.Stop())
+}
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(holmes.MetricSample{CPU: 10, Goroutine: 100})
+	c.Push(holmes.MetricSample{CPU: 20, Mem: 30, Goroutine: 200, Thread: 40})
+
+	cpu, mem, g, thread, err := c.Collect(1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{10, 0, 100, 0}, []int{cpu, mem, g, thread})
+	for i := 0; i < 2; i++ {
+		cpu, mem, g, thread, err = c.Collect(1, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{20, 30, 200, 40}, []int{cpu, mem, g, thread})
+	}
+	assert.Equal(t, 3, c.Count())
+}
+
+// testStart is the start time of the fake clocks.
+var testStart = time.Unix(1700000000, 0)
+
+// newTestHolmes returns a holmes with the fake clock, collector and reporter, which collects every 5s
+// and dumps into a temp dir, the opts are applied after them.
+func newTestHolmes(t *testing.T, opts ...holmes.Option) (*holmes.Holmes, *Clock, *Collector, *Reporter) {
+	dir, err := ioutil.TempDir("", "holmestest")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir) // nolint: errcheck
+	})
+
+	clock := NewClock(testStart)
+	collector := NewCollector()
+	reporter := NewReporter()
+	h, err := holmes.New(append([]holmes.Option{
+		holmes.WithClock(clock),
+		holmes.WithCollector(collector),
+		holmes.WithDumpPath(dir),
+		holmes.WithCollectInterval("5s"),
+		holmes.WithProfileReporter(reporter),
+	}, opts...)...)
+	assert.Nil(t, err)
+	return h, clock, collector, reporter
+}
+
+func TestHolmesGoroutineDump(t *testing.T) {
+	h, clock, collector, reporter := newTestHolmes(t, holmes.WithGoroutineDump(1000, 25, 4000, 0, time.Minute))
+	for i := 0; i < 10; i++ {
+		collector.Push(holmes.MetricSample{Goroutine: 100})
+	}
+	collector.Push(holmes.MetricSample{Goroutine: 5000})
+	h.EnableGoroutineDump()
+	h.Start()
+	defer h.Stop()
+	assert.True(t, clock.WaitTicker(time.Second))
+
+	// warming up in the first 10 cycles, dump in the 11th cycle.
+	for i := 0; i < 11; i++ {
+		clock.Advance(5 * time.Second)
+	}
+	reports, ok := reporter.Wait(1, 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, "goroutine", reports[0].PType)
+	assert.Equal(t, holmes.ReasonCurGreaterAbs, reports[0].Reason)
+	assert.Equal(t, testStart.Add(55*time.Second), reports[0].SampleTime)
+	assert.Equal(t, 5000, reports[0].Scene.CurVal)
+
+	// in cooldown before start + 115s, the cycles till start + 105s are done when the tick of 110s is received.
+	for i := 0; i < 11; i++ {
+		clock.Advance(5 * time.Second)
+	}
+	assert.Equal(t, 1, len(reporter.Reports()))
+
+	// the cycle of start + 115s dumps again.
+	clock.Advance(5 * time.Second)
+	reports, ok = reporter.Wait(2, 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, testStart.Add(115*time.Second), reports[1].SampleTime)
+}
+
+func TestHolmesRestart(t *testing.T) {
+	h, clock, collector, reporter := newTestHolmes(t, holmes.WithGoroutineDump(1000, 25, 4000, 0, time.Hour))
+	for i := 0; i < 10; i++ {
+		collector.Push(holmes.MetricSample{Goroutine: 100})
+	}
+	collector.Push(holmes.MetricSample{Goroutine: 5000})
+	h.EnableGoroutineDump()
+
+	h.Start()
+	assert.True(t, clock.WaitTicker(time.Second))
+	for i := 0; i < 11; i++ {
+		clock.Advance(5 * time.Second)
+	}
+	_, ok := reporter.Wait(1, 5*time.Second)
+	assert.True(t, ok)
+	assert.Nil(t, h.Shutdown(context.Background()))
+
+	// the cooldown of an hour and the warming up are reset after started again.
+	h.Start()
+	assert.True(t, clock.WaitTicker(time.Second))
+	for i := 0; i < 9; i++ {
+		clock.Advance(5 * time.Second)
+	}
+	assert.Equal(t, 1, len(reporter.Reports()))
+
+	clock.Advance(5 * time.Second)
+	reports, ok := reporter.Wait(2, 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, testStart.Add(105*time.Second), reports[1].SampleTime)
+	assert.Nil(t, h.Shutdown(context.Background()))
+}
+
+func TestHolmesStopStart(t *testing.T) {
+	h, clock, collector, _ := newTestHolmes(t,
+		holmes.WithGoroutineDump(1000, 25, 4000, 0, time.Minute),
+		holmes.WithGCHeapDump(10, 20, 40, time.Minute),
+	)
+	collector.Push(holmes.MetricSample{CPU: 10, Mem: 10, Goroutine: 5000, Thread: 10})
+	h.EnableGoroutineDump().EnableGCHeapDump()
+
+	// start again without waiting for the previous loops.
+	for i := 0; i < 5; i++ {
+		h.Start()
+		runtime.GC()
+		clock.Advance(time.Minute)
+		h.Stop()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Nil(t, h.Shutdown(ctx))
+}
+
+func TestHolmesCheckStatus(t *testing.T) {
+	h, clock, collector, reporter := newTestHolmes(t,
+		holmes.WithGoroutineDump(1000, 25, 4000, 0, time.Minute),
+		holmes.WithAllocRateDump(10000, 25, 20000, 0, time.Minute),
+	)
+	for i := 0; i < 10; i++ {
+		collector.Push(holmes.MetricSample{CPU: 10, Mem: 10, Goroutine: 100, Thread: 10})
+	}
+	collector.Push(holmes.MetricSample{CPU: 10, Mem: 10, Goroutine: 5000, Thread: 10})
+	h.EnableCPUDump().EnableMemDump().EnableThreadDump().EnableGoroutineDump().EnableGCHeapDump().
+		EnableAllocRateDump().EnableFDDump().EnableMemPressureDump().EnableCPUThrottleDump().
+		EnableGoroutineLeakDump().EnableShrinkThread()
+
+	// read the state concurrently with all the checks running.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			_ = h.CheckStatus()
+			runtime.Gosched()
+		}
+	}()
+
+	h.Start()
+	assert.True(t, clock.WaitTicker(time.Second))
+	for i := 0; i < 11; i++ {
+		runtime.GC()
+		clock.Advance(5 * time.Second)
+	}
+	_, ok := reporter.Wait(1, 5*time.Second)
+	assert.True(t, ok)
+	clock.Advance(5 * time.Second)
+	assert.Nil(t, h.Shutdown(context.Background()))
+	close(done)
+	wg.Wait()
+
+	var status holmes.CheckStatus
+	for _, s := range h.CheckStatus() {
+		if s.Check == "goroutine" {
+			status = s
+		}
+	}
+	// the cycle of start + 60s may be skipped since it's stopped.
+	assert.Equal(t, 10, len(status.History))
+	assert.Equal(t, 5000, status.History[9])
+	assert.Equal(t, 1, status.TriggerCount)
+	assert.Equal(t, testStart.Add(55*time.Second), status.LastDump)
+	assert.Equal(t, testStart.Add(115*time.Second), status.CoolDownUntil)
+}
+
+func TestHolmesCPUDump(t *testing.T) {
+	h, clock, _, _ := newTestHolmes(t, holmes.WithCPUSamplingTime("10s"))
+
+	type result struct {
+		files []string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		_, files, err := h.Dump(context.Background(), "cpu")
+		done <- result{files, err}
+	}()
+
+	// the cpu profile is sampled for 10s of the clock.
+	assert.True(t, clock.WaitTimer(5*time.Second))
+	clock.Advance(5 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("the cpu sampling is done early")
+	default:
+	}
+	clock.Advance(5 * time.Second)
+	r := <-done
+	assert.Nil(t, r.err)
+	assert.Equal(t, 1, len(r.files))
+	assert.FileExists(t, r.files[0])
+}
+
+// queueChecker is a custom checker of the queue length, which returns the lengths in order, and the last one repeatedly.
+type queueChecker struct {
+	mu      sync.Mutex
+	lengths []int
+}
+
+func (c *queueChecker) Name() string {
+	return "queue"
+}
+
+func (c *queueChecker) Collect() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.lengths[0]
+	if len(c.lengths) > 1 {
+		c.lengths = c.lengths[1:]
+	}
+	return n, true
+}
+
+func (c *queueChecker) Ring() int {
+	return 5
+}
+
+func (c *queueChecker) Options() holmes.CheckOptions {
+	return holmes.NewCheckOptions(100, 50, 1000, time.Minute)
+}
+
+func (c *queueChecker) Dump(d *holmes.DumpContext) error {
+	if _, err := d.WriteProfile("goroutine"); err != nil {
+		return err
+	}
+	_, err := d.WriteProfile("heap")
+	return err
+}
+
+func TestHolmesCustomChecker(t *testing.T) {
+	h, clock, collector, reporter := newTestHolmes(t)
+	collector.Push(holmes.MetricSample{Goroutine: 100})
+
+	checker := &queueChecker{}
+	for i := 0; i < 10; i++ {
+		checker.lengths = append(checker.lengths, 10)
+	}
+	checker.lengths = append(checker.lengths, 2000)
+	assert.Nil(t, h.RegisterChecker(checker))
+	assert.NotNil(t, h.RegisterChecker(checker))
+
+	h.Start()
+	assert.True(t, clock.WaitTicker(time.Second))
+	for i := 0; i < 11; i++ {
+		clock.Advance(5 * time.Second)
+	}
+	reports, ok := reporter.Wait(2, 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"goroutine", "heap"}, []string{reports[0].PType, reports[1].PType})
+	for _, r := range reports {
+		assert.Equal(t, "queue-0", r.EventID)
+		assert.Equal(t, holmes.ReasonCurGreaterAbs, r.Reason)
+		assert.Equal(t, 2000, r.Scene.CurVal)
+		// the average of the recent 5 values including the current one.
+		assert.Equal(t, 408, r.Scene.Avg)
+		assert.FileExists(t, r.FileName)
+	}
+	assert.Nil(t, h.Shutdown(context.Background()))
+
+	status := h.CheckStatus()
+	var queue holmes.CheckStatus
+	for _, s := range status {
+		if s.Check == "queue" {
+			queue = s
+		}
+	}
+	assert.Equal(t, 1, queue.TriggerCount)
+	assert.Equal(t, testStart.Add(55*time.Second), queue.LastDump)
+	assert.Equal(t, 5, len(queue.History))
+}
+
+func TestHolmesMetricDump(t *testing.T) {
+	h, clock, collector, reporter := newTestHolmes(t)
+	collector.Push(holmes.MetricSample{Goroutine: 100})
+
+	var waiters int64 = 2
+	assert.Nil(t, h.RegisterMetric("dbwaiters", func() int {
+		return int(atomic.LoadInt64(&waiters))
+	}, holmes.NewCheckOptions(10, 50, 100, time.Minute), "goroutine"))
+
+	h.Start()
+	assert.True(t, clock.WaitTicker(time.Second))
+	for i := 0; i < 11; i++ {
+		clock.Advance(5 * time.Second)
+	}
+	assert.Equal(t, 0, len(reporter.Reports()))
+
+	atomic.StoreInt64(&waiters, 500)
+	clock.Advance(5 * time.Second)
+	reports, ok := reporter.Wait(1, 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, "goroutine", reports[0].PType)
+	assert.Equal(t, "dbwaiters", reports[0].Scene.Check)
+	assert.Equal(t, "dbwaiters-0", reports[0].EventID)
+	assert.Equal(t, 500, reports[0].Scene.CurVal)
+	assert.Nil(t, h.Shutdown(context.Background()))
+}
@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmestest
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/holmes"
+)
+
+// Report is a profile reported to the Reporter.
+type Report struct {
+	PType      string
+	FileName   string
+	Reason     holmes.ReasonType
+	EventID    string
+	SampleTime time.Time
+	PprofBytes []byte
+	Scene      holmes.Scene
+}
+
+// Reporter is a holmes.ProfileReporter recording the reports.
+type Reporter struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	reports []Report
+}
+
+// NewReporter returns an empty Reporter.
+func NewReporter() *Reporter {
+	r := &Reporter{}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// Report records the report.
+func (r *Reporter) Report(pType string, filename string, reason holmes.ReasonType, eventID string,
+	sampleTime time.Time, pprofBytes []byte, scene holmes.Scene) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, Report{
+		PType:      pType,
+		FileName:   filename,
+		Reason:     reason,
+		EventID:    eventID,
+		SampleTime: sampleTime,
+		PprofBytes: pprofBytes,
+		Scene:      scene,
+	})
+	r.cond.Broadcast()
+	return nil
+}
+
+// Reports returns a copy of the recorded reports.
+func (r *Reporter) Reports() []Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Report(nil), r.reports...)
+}
+
+// Wait waits until at least n reports are recorded or timeout, returns the recorded reports,
+// and whether there are n reports at least.
+func (r *Reporter) Wait(n int, timeout time.Duration) ([]Report, bool) {
+	timer := time.AfterFunc(timeout, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.cond.Broadcast()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.reports) < n && time.Now().Before(deadline) {
+		r.cond.Wait()
+	}
+	return append([]Report(nil), r.reports...), len(r.reports) >= n
+}
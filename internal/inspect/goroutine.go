@@ -0,0 +1,221 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const createdByPrefix = "created by "
+
+// GoroutineGroup is a group of goroutines with identical stack and state,
+// parsed from the goroutine dump with debug=2.
+type GoroutineGroup struct {
+	// the goroutine state, like "chan receive", "IO wait, locked to thread".
+	State string
+	// the number of goroutines in this group.
+	Count int
+	// the min and max wait duration of the goroutines in this group,
+	// runtime only reports it in minutes when the goroutine is blocked for more than one minute.
+	MinWait time.Duration
+	MaxWait time.Duration
+	// the stack frames, each frame is the function name and the file:line.
+	Stack []string
+	// the frame of the function which created the goroutines, empty for the main goroutine.
+	CreatedBy string
+}
+
+// goroutineRecord is a goroutine in the goroutine dump with debug=2.
+type goroutineRecord struct {
+	id        int
+	state     string
+	wait      time.Duration
+	stack     []string
+	createdBy string
+}
+
+// parseGoroutineDump parses the goroutine dump with debug=2.
+func parseGoroutineDump(data []byte) []goroutineRecord {
+	var records []goroutineRecord
+	for _, block := range strings.Split(string(data), "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		record, ok := parseGoroutineHeader(lines[0])
+		if !ok {
+			continue
+		}
+
+		for i := 1; i < len(lines); i++ {
+			frame := trimFrameFunc(lines[i])
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+				i++
+				frame += " " + trimFrameFile(lines[i])
+			}
+			if strings.HasPrefix(frame, createdByPrefix) {
+				record.createdBy = strings.TrimPrefix(frame, createdByPrefix)
+				continue
+			}
+			record.stack = append(record.stack, frame)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// parseGoroutineHeader parses the header like "goroutine 18 [chan receive, 30 minutes]:".
+func parseGoroutineHeader(line string) (goroutineRecord, bool) {
+	var record goroutineRecord
+	if !strings.HasPrefix(line, "goroutine ") || !strings.HasSuffix(line, "]:") {
+		return record, false
+	}
+	fields := strings.Fields(line)
+	id, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return record, false
+	}
+	record.id = id
+
+	start := strings.Index(line, "[")
+	if start < 0 {
+		return record, false
+	}
+	var states []string
+	for _, s := range strings.Split(line[start+1:len(line)-2], ", ") {
+		if strings.HasSuffix(s, " minutes") {
+			if minutes, err := strconv.Atoi(strings.TrimSuffix(s, " minutes")); err == nil {
+				record.wait = time.Duration(minutes) * time.Minute
+				continue
+			}
+		}
+		states = append(states, s)
+	}
+	record.state = strings.Join(states, ", ")
+	return record, true
+}
+
+// trimFrameFunc trims the arguments and goroutine id of the function line, e.g.
+// "main.worker(0xc00001e0c0, ...)" => "main.worker",
+// "created by main.main in goroutine 1" => "created by main.main".
+func trimFrameFunc(line string) string {
+	if strings.HasPrefix(line, createdByPrefix) {
+		if i := strings.Index(line, " in goroutine "); i > 0 {
+			return line[:i]
+		}
+		return line
+	}
+	if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndex(line, "("); i > 0 {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// trimFrameFile trims the pc offset of the file line, e.g. "\t/path/main.go:23 +0x25" => "/path/main.go:23".
+func trimFrameFile(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.LastIndex(line, " +0x"); i > 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// GroupKey returns the key to identify the goroutines with identical stack and state.
+func GroupKey(state string, stack []string, createdBy string) string {
+	return state + "\n" + strings.Join(stack, "\n") + "\n" + createdBy
+}
+
+// aggregateGoroutines groups the goroutines by identical stack and state, sorted by count.
+func aggregateGoroutines(records []goroutineRecord) []GoroutineGroup {
+	index := make(map[string]int)
+	var groups []GoroutineGroup
+	for _, r := range records {
+		key := GroupKey(r.state, r.stack, r.createdBy)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, GoroutineGroup{
+				State:     r.state,
+				MinWait:   r.wait,
+				MaxWait:   r.wait,
+				Stack:     r.stack,
+				CreatedBy: r.createdBy,
+			})
+		}
+		g := &groups[i]
+		g.Count++
+		if r.wait < g.MinWait {
+			g.MinWait = r.wait
+		}
+		if r.wait > g.MaxWait {
+			g.MaxWait = r.wait
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups
+}
+
+// SummarizeGoroutineDump returns the top n goroutine groups of the goroutine dump with debug=2,
+// and the total number of goroutines.
+func SummarizeGoroutineDump(data []byte, n int) ([]GoroutineGroup, int) {
+	records := parseGoroutineDump(data)
+	groups := aggregateGoroutines(records)
+	if n > 0 && len(groups) > n {
+		groups = groups[:n]
+	}
+	return groups, len(records)
+}
+
+// FormatGoroutineGroups formats the goroutine groups as a compact summary.
+func FormatGoroutineGroups(groups []GoroutineGroup, total int) bytes.Buffer {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "goroutine summary: %d goroutines, top %d groups\n", total, len(groups))
+
+	for _, g := range groups {
+		fmt.Fprintf(&buf, "\n%d goroutines", g.Count)
+		// the groups of goroutine profile have no state.
+		if g.State != "" {
+			fmt.Fprintf(&buf, " [%s", g.State)
+			switch {
+			case g.MaxWait == 0:
+			case g.MinWait == g.MaxWait:
+				fmt.Fprintf(&buf, ", wait %v", g.MaxWait)
+			default:
+				fmt.Fprintf(&buf, ", wait %v - %v", g.MinWait, g.MaxWait)
+			}
+			buf.WriteString("]")
+		}
+		buf.WriteString(":\n")
+
+		for _, frame := range g.Stack {
+			fmt.Fprintf(&buf, "\t%s\n", frame)
+		}
+		if g.CreatedBy != "" {
+			fmt.Fprintf(&buf, "\tcreated by %s\n", g.CreatedBy)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inspect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGoroutineDump = `goroutine 1 [running]:
+main.main()
+	/tmp/g.go:20 +0x13d
+
+goroutine 7 [chan receive, 30 minutes]:
+main.worker(0xc00001e0c0)
+	/tmp/g.go:15 +0x19
+created by main.main in goroutine 1
+	/tmp/g.go:15 +0x65
+
+goroutine 8 [chan receive, 45 minutes]:
+main.worker(0xc00001e0c8)
+	/tmp/g.go:15 +0x19
+created by main.main in goroutine 1
+	/tmp/g.go:15 +0x65
+
+goroutine 9 [chan receive]:
+main.worker(0xc00001e0d0)
+	/tmp/g.go:15 +0x19
+created by main.main in goroutine 1
+	/tmp/g.go:15 +0x65
+
+goroutine 10 [sync.Mutex.Lock, 2 minutes, locked to thread]:
+sync.(*Mutex).Lock(...)
+	/usr/local/go/src/sync/mutex.go:46
+main.lock()
+	/tmp/g.go:17 +0x2c
+created by main.main in goroutine 1
+	/tmp/g.go:17 +0x105
+`
+
+func TestSummarizeGoroutineDump(t *testing.T) {
+	groups, total := SummarizeGoroutineDump([]byte(testGoroutineDump), 2)
+	assert.Equal(t, 5, total)
+	assert.Equal(t, []GoroutineGroup{
+		{
+			State:     "chan receive",
+			Count:     3,
+			MinWait:   0,
+			MaxWait:   45 * time.Minute,
+			Stack:     []string{"main.worker /tmp/g.go:15"},
+			CreatedBy: "main.main /tmp/g.go:15",
+		},
+		{
+			State: "running",
+			Count: 1,
+			Stack: []string{"main.main /tmp/g.go:20"},
+		},
+	}, groups)
+
+	groups, _ = SummarizeGoroutineDump([]byte(testGoroutineDump), 0)
+	assert.Equal(t, 3, len(groups))
+	assert.Equal(t, "sync.Mutex.Lock, locked to thread", groups[2].State)
+	assert.Equal(t, 2*time.Minute, groups[2].MaxWait)
+	assert.Equal(t, "sync.(*Mutex).Lock /usr/local/go/src/sync/mutex.go:46", groups[2].Stack[0])
+
+	buf := FormatGoroutineGroups(groups[:1], total)
+	assert.Equal(t, `goroutine summary: 5 goroutines, top 1 groups
+
+3 goroutines [chan receive, wait 0s - 45m0s]:
+	main.worker /tmp/g.go:15
+	created by main.main /tmp/g.go:15
+`, buf.String())
+}
@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package inspect renders, diffs and summarizes the dumped profiles, it is shared by holmes and the holmes command.
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// ProfileTop renders the profile in binary or legacy text format as a top n report
+// ranked by the flat or cumulative value of the sample type.
+func ProfileTop(data []byte, sampleType string, n int, sortBy SortKey) ([]byte, error) {
+	p, err := profile.ParseData(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.SampleType) == 0 {
+		return nil, fmt.Errorf("profile has no sample type")
+	}
+	// the legacy text profile isn't symbolized.
+	if len(p.Sample) > 0 && len(p.Function) == 0 {
+		return nil, fmt.Errorf("profile isn't symbolized")
+	}
+	buf := FormatTop(p, sampleType, n, sortBy)
+	return buf.Bytes(), nil
+}
+
+// DiffProfiles returns the delta profile of cur - base in binary,
+// and the top n functions whose flat value of the sample type grows most.
+func DiffProfiles(base, cur []byte, sampleType string, n int) ([]byte, []byte, error) {
+	diff, err := Delta(base, cur)
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	if err := diff.Write(&buf); err != nil {
+		return nil, nil, err
+	}
+	top := FormatDeltaTop(diff, sampleType, n)
+	return buf.Bytes(), top.Bytes(), nil
+}
+
+// SummarizeGoroutines summarizes the goroutine dump as the top n groups of goroutines with identical stack.
+// the dump could be in binary, or text with debug=1 or debug=2, only the dump with debug=2 has the state and wait.
+func SummarizeGoroutines(data []byte, n int) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte("goroutine ")) && !bytes.HasPrefix(data, []byte("goroutine profile:")) {
+		groups, total := SummarizeGoroutineDump(data, n)
+		buf := FormatGoroutineGroups(groups, total)
+		return buf.Bytes(), nil
+	}
+
+	var groups []GoroutineGroup
+	var total int
+	if bytes.HasPrefix(data, []byte("goroutine profile:")) {
+		groups, total = parseGoroutineProfileText(data)
+	} else {
+		p, err := profile.ParseData(data)
+		if err != nil {
+			return nil, err
+		}
+		groups, total = goroutineGroupsOfProfile(p)
+	}
+	if n > 0 && len(groups) > n {
+		groups = groups[:n]
+	}
+	buf := FormatGoroutineGroups(groups, total)
+	return buf.Bytes(), nil
+}
+
+// goroutineGroupsOfProfile groups the samples of goroutine profile by stack, sorted by count.
+func goroutineGroupsOfProfile(p *profile.Profile) ([]GoroutineGroup, int) {
+	index := make(map[string]int)
+	var groups []GoroutineGroup
+	total := 0
+	for _, s := range p.Sample {
+		var stack []string
+		for _, loc := range s.Location {
+			for _, line := range loc.Line {
+				if line.Function == nil {
+					continue
+				}
+				stack = append(stack, fmt.Sprintf("%s %s:%d", line.Function.Name, line.Function.Filename, line.Line))
+			}
+		}
+		key := GroupKey("", stack, "")
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, GoroutineGroup{Stack: stack})
+		}
+		groups[i].Count += int(s.Value[0])
+		total += int(s.Value[0])
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups, total
+}
+
+// parseGoroutineProfileText parses the goroutine profile with debug=1, sorted by count, it's like:
+//
+//	goroutine profile: total 7
+//	5 @ 0x43a1b6 0x4065ec 0x4660e1
+//	#	0x4065eb	main.worker+0x2b	/path/main.go:23
+func parseGoroutineProfileText(data []byte) ([]GoroutineGroup, int) {
+	var groups []GoroutineGroup
+	total := 0
+	// skip the header "goroutine profile: total 7".
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	for _, block := range strings.Split(string(data), "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		fields := strings.Fields(lines[0])
+		if len(fields) < 2 || fields[1] != "@" {
+			continue
+		}
+		count, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		var stack []string
+		for _, line := range lines[1:] {
+			// "#", pc, function+offset, file:line
+			frame := strings.Fields(line)
+			if len(frame) < 4 || frame[0] != "#" {
+				continue
+			}
+			name := frame[2]
+			if i := strings.LastIndex(name, "+0x"); i > 0 {
+				name = name[:i]
+			}
+			stack = append(stack, name+" "+frame[3])
+		}
+		groups = append(groups, GoroutineGroup{Count: count, Stack: stack})
+		total += count
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups, total
+}
+
+// IsBinaryProfile reports whether the data is a binary profile, which is gzipped.
+func IsBinaryProfile(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeGoroutines(t *testing.T) {
+	debug1 := `goroutine profile: total 4
+3 @ 0x43a1b6 0x4065ec 0x4660e1
+#	0x4065eb	main.worker+0x2b	/path/main.go:23
+
+1 @ 0x43a1b6 0x4660e1
+#	0x4660e0	main.main+0x20	/path/main.go:10
+`
+	summary, err := SummarizeGoroutines([]byte(debug1), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, `goroutine summary: 4 goroutines, top 2 groups
+
+3 goroutines:
+	main.worker /path/main.go:23
+
+1 goroutines:
+	main.main /path/main.go:10
+`, string(summary))
+
+	debug2 := `goroutine 1 [chan receive, 3 minutes]:
+main.worker(0xc00001e0c0)
+	/path/main.go:23 +0x25
+created by main.main in goroutine 1
+	/path/main.go:10 +0x20
+`
+	summary, err = SummarizeGoroutines([]byte(debug2), 10)
+	assert.Nil(t, err)
+	assert.Contains(t, string(summary), "1 goroutines [chan receive, wait 3m0s]:")
+
+	_, err = SummarizeGoroutines([]byte("not a dump"), 10)
+	assert.NotNil(t, err)
+}
+
+func TestProfileTopAndDiff(t *testing.T) {
+	base := newTestHeapProfile(t, map[string]int64{"main.leak": 1 << 20})
+	cur := newTestHeapProfile(t, map[string]int64{"main.leak": 3 << 20})
+
+	top, err := ProfileTop(cur, "inuse_space", 1, SortByFlat)
+	assert.Nil(t, err)
+	assert.Contains(t, string(top), "main.leak main.go:2")
+
+	diff, diffTop, err := DiffProfiles(base, cur, "inuse_space", 10)
+	assert.Nil(t, err)
+	assert.True(t, IsBinaryProfile(diff))
+	assert.Contains(t, string(diffTop), "+2.00MB")
+
+	_, err = ProfileTop([]byte("not a profile"), "", 10, SortByFlat)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// Entry is the flat and cumulative value of a function in a profile.
+type Entry struct {
+	// function name and the file:line of its first sample location.
+	Func string
+	File string
+	Flat int64
+	Cum  int64
+}
+
+// SampleIndex returns the index of the sample type, the last one is used when it's not found.
+func SampleIndex(p *profile.Profile, sampleType string) int {
+	for i, st := range p.SampleType {
+		if st.Type == sampleType {
+			return i
+		}
+	}
+	return len(p.SampleType) - 1
+}
+
+// AggregateByFunc aggregates the sample values by function,
+// the flat value is attributed to the leaf function, and the cumulative value to each function in the stack.
+func AggregateByFunc(p *profile.Profile, idx int) []Entry {
+	index := make(map[string]int)
+	var entries []Entry
+
+	entry := func(line profile.Line) *Entry {
+		name := "unknown"
+		if line.Function != nil {
+			name = line.Function.Name
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(entries)
+			index[name] = i
+			e := Entry{Func: name}
+			if line.Function != nil {
+				e.File = fmt.Sprintf("%s:%d", line.Function.Filename, line.Line)
+			}
+			entries = append(entries, e)
+		}
+		return &entries[i]
+	}
+
+	for _, s := range p.Sample {
+		v := s.Value[idx]
+		if v == 0 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for i, loc := range s.Location {
+			for j, line := range loc.Line {
+				e := entry(line)
+				// the first line of the first location is the leaf function.
+				if i == 0 && j == 0 {
+					e.Flat += v
+				}
+				if !seen[e.Func] {
+					seen[e.Func] = true
+					e.Cum += v
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// SortKey is the value to rank the functions in the top n report.
+type SortKey int
+
+const (
+	// SortByFlat ranks by the value of the function itself.
+	SortByFlat SortKey = iota
+	// SortByCum ranks by the value of the function and its callees.
+	SortByCum
+)
+
+func (k SortKey) String() string {
+	if k == SortByCum {
+		return "cum"
+	}
+	return "flat"
+}
+
+// FormatTop formats the top n functions of the profile ranked by sortBy value of the sample type.
+func FormatTop(p *profile.Profile, sampleType string, n int, sortBy SortKey) bytes.Buffer {
+	idx := SampleIndex(p, sampleType)
+	st := p.SampleType[idx]
+
+	var total int64
+	for _, s := range p.Sample {
+		total += s.Value[idx]
+	}
+
+	entries := AggregateByFunc(p, idx)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if sortBy == SortByCum {
+			return entries[i].Cum > entries[j].Cum
+		}
+		return entries[i].Flat > entries[j].Flat
+	})
+
+	shown := len(entries)
+	if n > 0 && n < shown {
+		shown = n
+	}
+
+	percent := func(v int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(v) * 100 / float64(total)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Type: %s\n", st.Type)
+	fmt.Fprintf(&buf, "Showing top %d of %d functions, sorted by %s, total: %s\n",
+		shown, len(entries), sortBy, FormatValue(total, st.Unit, false))
+	fmt.Fprintf(&buf, "%12s %7s %7s %12s %7s\n", "flat", "flat%", "sum%", "cum", "cum%")
+	var sum int64
+	for _, e := range entries[:shown] {
+		sum += e.Flat
+		fmt.Fprintf(&buf, "%12s %6.2f%% %6.2f%% %12s %6.2f%%  %s %s\n",
+			FormatValue(e.Flat, st.Unit, false), percent(e.Flat), percent(sum),
+			FormatValue(e.Cum, st.Unit, false), percent(e.Cum), e.Func, e.File)
+	}
+	return buf
+}
+
+// Delta returns the delta profile of cur - base, they must be the same kind of profile.
+func Delta(base, cur []byte) (*profile.Profile, error) {
+	bp, err := profile.ParseData(base)
+	if err != nil {
+		return nil, fmt.Errorf("parse base profile failed: %w", err)
+	}
+	cp, err := profile.ParseData(cur)
+	if err != nil {
+		return nil, fmt.Errorf("parse profile failed: %w", err)
+	}
+
+	// scale the base to the same duration, e.g. cpu profiles sampled in different time.
+	ratio := -1.0
+	if bp.DurationNanos > 0 && cp.DurationNanos > 0 {
+		ratio = -float64(cp.DurationNanos) / float64(bp.DurationNanos)
+	}
+	bp.Scale(ratio)
+	diff, err := profile.Merge([]*profile.Profile{cp, bp})
+	if err != nil {
+		return nil, fmt.Errorf("merge profiles failed: %w", err)
+	}
+	return diff, nil
+}
+
+// FormatDeltaTop formats the top n functions whose flat value grows most in the delta profile.
+func FormatDeltaTop(diff *profile.Profile, sampleType string, n int) bytes.Buffer {
+	idx := SampleIndex(diff, sampleType)
+	st := diff.SampleType[idx]
+
+	var total int64
+	for _, s := range diff.Sample {
+		total += s.Value[idx]
+	}
+
+	entries := AggregateByFunc(diff, idx)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Flat > entries[j].Flat
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "delta of %s, total: %s\n", st.Type, FormatValue(total, st.Unit, true))
+	fmt.Fprintf(&buf, "%12s %12s  %s\n", "flat", "cum", "function")
+	for i, e := range entries {
+		if i >= n || e.Flat <= 0 {
+			break
+		}
+		fmt.Fprintf(&buf, "%12s %12s  %s %s\n",
+			FormatValue(e.Flat, st.Unit, true), FormatValue(e.Cum, st.Unit, true), e.Func, e.File)
+	}
+	return buf
+}
+
+// FormatValue formats the sample value in human readable, with "+" prefix for positive value if signed.
+func FormatValue(v int64, unit string, signed bool) string {
+	sign := ""
+	if signed && v > 0 {
+		sign = "+"
+	}
+
+	abs := v
+	if abs < 0 {
+		abs = -abs
+	}
+	f := float64(v)
+	switch unit {
+	case "bytes":
+		switch {
+		case abs >= 1<<30:
+			return fmt.Sprintf("%s%.2fGB", sign, f/(1<<30))
+		case abs >= 1<<20:
+			return fmt.Sprintf("%s%.2fMB", sign, f/(1<<20))
+		case abs >= 1<<10:
+			return fmt.Sprintf("%s%.2fkB", sign, f/(1<<10))
+		}
+		return fmt.Sprintf("%s%dB", sign, v)
+	case "nanoseconds":
+		return sign + time.Duration(v).String()
+	}
+	return fmt.Sprintf("%s%d", sign, v)
+}
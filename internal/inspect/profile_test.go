@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inspect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestHeapProfile builds a heap profile, values are the inuse_space of the leaf functions.
+func newTestHeapProfile(t *testing.T, values map[string]int64) []byte {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "inuse_objects", Unit: "count"}, {Type: "inuse_space", Unit: "bytes"}},
+		PeriodType: &profile.ValueType{Type: "space", Unit: "bytes"},
+	}
+	main := &profile.Function{ID: 1, Name: "main.main", Filename: "main.go"}
+	mainLoc := &profile.Location{ID: 1, Line: []profile.Line{{Function: main, Line: 10}}}
+	p.Function = append(p.Function, main)
+	p.Location = append(p.Location, mainLoc)
+
+	id := uint64(2)
+	for _, name := range []string{"main.leak", "main.stable"} {
+		v, ok := values[name]
+		if !ok {
+			continue
+		}
+		fn := &profile.Function{ID: id, Name: name, Filename: "main.go"}
+		loc := &profile.Location{ID: id, Line: []profile.Line{{Function: fn, Line: int64(id)}}}
+		p.Function = append(p.Function, fn)
+		p.Location = append(p.Location, loc)
+		p.Sample = append(p.Sample, &profile.Sample{Location: []*profile.Location{loc, mainLoc}, Value: []int64{1, v}})
+		id++
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, p.Write(&buf))
+	return buf.Bytes()
+}
+
+func TestDelta(t *testing.T) {
+	base := newTestHeapProfile(t, map[string]int64{"main.leak": 1 << 20, "main.stable": 1 << 10})
+	cur := newTestHeapProfile(t, map[string]int64{"main.leak": 5 << 20, "main.stable": 1 << 10})
+
+	diff, err := Delta(base, cur)
+	assert.Nil(t, err)
+
+	buf := FormatDeltaTop(diff, "inuse_space", 10)
+	assert.Equal(t, `delta of inuse_space, total: +4.00MB
+        flat          cum  function
+     +4.00MB      +4.00MB  main.leak main.go:2
+`, buf.String())
+
+	_, err = Delta(base, []byte("not a profile"))
+	assert.NotNil(t, err)
+}
+
+func TestFormatProfileValue(t *testing.T) {
+	assert.Equal(t, "+1.50MB", FormatValue(3<<19, "bytes", true))
+	assert.Equal(t, "-2.00kB", FormatValue(-2<<10, "bytes", true))
+	assert.Equal(t, "512B", FormatValue(512, "bytes", false))
+	assert.Equal(t, "10ms", FormatValue(10e6, "nanoseconds", false))
+	assert.Equal(t, "+3", FormatValue(3, "count", true))
+}
+
+func TestFormatProfileTop(t *testing.T) {
+	p, err := profile.ParseData(newTestHeapProfile(t, map[string]int64{"main.leak": 3 << 20, "main.stable": 1 << 20}))
+	assert.Nil(t, err)
+
+	buf := FormatTop(p, "inuse_space", 1, SortByFlat)
+	assert.Equal(t, `Type: inuse_space
+Showing top 1 of 3 functions, sorted by flat, total: 4.00MB
+        flat   flat%    sum%          cum    cum%
+      3.00MB  75.00%  75.00%       3.00MB  75.00%  main.leak main.go:2
+`, buf.String())
+
+	buf = FormatTop(p, "inuse_objects", 10, SortByCum)
+	assert.Equal(t, `Type: inuse_objects
+Showing top 3 of 3 functions, sorted by cum, total: 2
+        flat   flat%    sum%          cum    cum%
+           0   0.00%   0.00%            2 100.00%  main.main main.go:10
+           1  50.00%  50.00%            1  50.00%  main.leak main.go:2
+           1  50.00% 100.00%            1  50.00%  main.stable main.go:3
+`, buf.String())
+}
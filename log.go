@@ -18,68 +18,133 @@
 package holmes
 
 import (
-	mlog "mosn.io/pkg/log"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
 )
 
-func (h *Holmes) getLogger() mlog.ErrorLogger {
-	h.opts.L.RLock()
-	defer h.opts.L.RUnlock()
-	return h.opts.logger
-}
+// LogLevel is the level of the holmes logs.
+type LogLevel int
 
-func (h *Holmes) Debugf(format string, args ...interface{}) {
-	logger := h.getLogger()
-	if logger == nil {
-		return
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
 	}
-	logger.Debugf(format, args...)
+	return "ERROR"
 }
 
-func (h *Holmes) Infof(format string, args ...interface{}) {
-	logger := h.getLogger()
-	if logger == nil {
-		return
-	}
-	logger.Infof(format, args...)
+// the keys of the structured fields in holmes logs.
+const (
+	// LogKeyAlert is the alert name, e.g. holmes.goroutine, set for the error logs of triggered dumps.
+	LogKeyAlert = "alert"
+	// LogKeyCheck is the check type, e.g. goroutine, mem, cpu.
+	LogKeyCheck = "check"
+	// LogKeyReason is the ReasonType of the decision.
+	LogKeyReason = "reason"
+	// LogKeyEventID is the event ID of the dump, the same as in the dump file names.
+	LogKeyEventID = "event_id"
+)
+
+// Logger is the logger of holmes, keyvals are the structured fields in key/value pairs,
+// the keys are strings of LogKeyAlert, LogKeyCheck, LogKeyReason and LogKeyEventID.
+type Logger interface {
+	Log(level LogLevel, msg string, keyvals ...interface{})
 }
 
-func (h *Holmes) Warnf(format string, args ...interface{}) {
-	logger := h.getLogger()
-	if logger == nil {
-		return
+// formatKeyvals formats the key/value pairs as " k1=v1 k2=v2".
+func formatKeyvals(keyvals []interface{}) string {
+	var buf bytes.Buffer
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&buf, " %v=%v", keyvals[i], keyvals[i+1])
 	}
-	logger.Warnf(format, args...)
+	return buf.String()
 }
 
-func (h *Holmes) Errorf(format string, args ...interface{}) {
-	logger := h.getLogger()
-	if logger == nil {
+type stdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+// NewStdLogAdapter adapts the logger of the standard log package to Logger,
+// the logs lower than level are dropped, the fields are appended to the message.
+func NewStdLogAdapter(logger *log.Logger, level LogLevel) Logger {
+	return &stdLogger{logger: logger, level: level}
+}
+
+func (l *stdLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	if level < l.level {
 		return
 	}
-	logger.Errorf(format, args...)
+	l.logger.Printf("[%v] %s%s", level, msg, formatKeyvals(keyvals))
 }
 
-func (h *Holmes) Alertf(alert string, format string, args ...interface{}) {
+// newDefaultLogger returns the default logger writing all the logs to os.Stdout.
+func newDefaultLogger() Logger {
+	return NewStdLogAdapter(log.New(os.Stdout, "", log.LstdFlags), LevelDebug)
+}
+
+func (h *Holmes) getLogger() Logger {
+	h.opts.L.RLock()
+	defer h.opts.L.RUnlock()
+	return h.opts.logger
+}
+
+// logw writes the log with the structured fields in key/value pairs.
+func (h *Holmes) logw(level LogLevel, keyvals []interface{}, format string, args ...interface{}) {
 	logger := h.getLogger()
 	if logger == nil {
 		return
 	}
-	logger.Alertf(alert, format, args...)
+	logger.Log(level, fmt.Sprintf(format, args...), keyvals...)
 }
 
-// NewStdLogger create an ErrorLogger interface value that writing to os.Stdout
-func NewStdLogger() mlog.ErrorLogger {
-	logger, _ := mlog.GetOrCreateLogger("stdout", nil)
-	return &mlog.SimpleErrorLog{
-		Logger: logger,
-		Level:  mlog.DEBUG,
+// checkFields returns the structured fields of the check, the reason and event ID are omitted when not set.
+func checkFields(check string, reason *ReasonType, eventID string) []interface{} {
+	fields := []interface{}{LogKeyCheck, check}
+	if reason != nil {
+		fields = append(fields, LogKeyReason, reason.String())
+	}
+	if eventID != "" {
+		fields = append(fields, LogKeyEventID, eventID)
 	}
+	return fields
 }
 
-func NewFileLog(path string, level mlog.Level) mlog.ErrorLogger {
-	logger, _ := mlog.GetOrCreateLogger(path, nil)
-	return &mlog.SimpleErrorLog{
-		Logger: logger,
-		Level:  level,
-	}
+// alertCheck writes the alert of the triggered check, with the structured fields of the check.
+func (h *Holmes) alertCheck(alert string, check string, reason ReasonType, eventID string, format string, args ...interface{}) {
+	fields := append([]interface{}{LogKeyAlert, alert}, checkFields(check, &reason, eventID)...)
+	h.logw(LevelError, fields, format, args...)
+}
+
+func (h *Holmes) Debugf(format string, args ...interface{}) {
+	h.logw(LevelDebug, nil, format, args...)
+}
+
+func (h *Holmes) Infof(format string, args ...interface{}) {
+	h.logw(LevelInfo, nil, format, args...)
+}
+
+func (h *Holmes) Warnf(format string, args ...interface{}) {
+	h.logw(LevelWarn, nil, format, args...)
+}
+
+func (h *Holmes) Errorf(format string, args ...interface{}) {
+	h.logw(LevelError, nil, format, args...)
+}
+
+func (h *Holmes) Alertf(alert string, format string, args ...interface{}) {
+	h.logw(LevelError, []interface{}{LogKeyAlert, alert}, format, args...)
 }
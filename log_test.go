@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logRecord struct {
+	level   LogLevel
+	msg     string
+	keyvals []interface{}
+}
+
+type recordLogger struct {
+	records []logRecord
+}
+
+func (l *recordLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	l.records = append(l.records, logRecord{level: level, msg: msg, keyvals: keyvals})
+}
+
+func TestStdLogAdapter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogAdapter(log.New(&buf, "", 0), LevelInfo)
+
+	logger.Log(LevelDebug, "dropped")
+	logger.Log(LevelError, "goroutine dumped", LogKeyCheck, "goroutine", LogKeyEventID, "thr-1")
+	assert.Equal(t, "[ERROR] goroutine dumped check=goroutine event_id=thr-1\n", buf.String())
+}
+
+func TestStructuredLogger(t *testing.T) {
+	rec := &recordLogger{}
+	h, err := New(WithStructuredLogger(rec))
+	assert.Nil(t, err)
+
+	h.alertCheck("holmes.thread", check2name[thread], ReasonCurGreaterAbs, "thr-1", "thread %v", 100)
+	h.logNoDump(check2name[mem], typeOption{TriggerMin: 10}, NotSupportTypeMaxConfig, newRing(2), 5, ReasonCurlLessMin)
+	h.Infof("[Holmes] %v", "plain")
+
+	assert.Equal(t, []logRecord{
+		{level: LevelError, msg: "thread 100", keyvals: []interface{}{
+			LogKeyAlert, "holmes.thread", LogKeyCheck, "thread",
+			LogKeyReason, ReasonCurGreaterAbs.String(), LogKeyEventID, "thr-1"}},
+		{level: LevelInfo, msg: "[Holmes] NODUMP mem, config_min : 10, config_diff : 0, config_abs : 0, config_max : 0, previous : [0 0], current: 5",
+			keyvals: []interface{}{LogKeyCheck, "mem", LogKeyReason, ReasonCurlLessMin.String()}},
+		{level: LevelInfo, msg: "[Holmes] plain"},
+	}, rec.records)
+
+	// disabled
+	h, err = New(WithStructuredLogger(nil))
+	assert.Nil(t, err)
+	h.Infof("nothing")
+}
+
+func TestStopTwice(t *testing.T) {
+	rec := &recordLogger{}
+	h, err := New(WithStructuredLogger(rec))
+	assert.Nil(t, err)
+	h.Start()
+	assert.Nil(t, h.Shutdown(context.Background()))
+
+	rec.records = nil
+	h.Stop()
+	assert.Equal(t, []logRecord{
+		{level: LevelInfo, msg: "[Holmes] holmes has stopped, please don't stop it again"},
+	}, rec.records)
+}
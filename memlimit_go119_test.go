@@ -0,0 +1,45 @@
+//go:build go1.19
+// +build go1.19
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMemoryLimitByGoMemLimit(t *testing.T) {
+	hh, _ := New(WithGoMemLimit(true))
+
+	old := debug.SetMemoryLimit(64 << 20)
+	defer debug.SetMemoryLimit(old)
+
+	limit, err := hh.getMemoryLimit()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(64<<20), limit)
+
+	// the option memory limit takes precedence
+	_ = hh.Set(WithMemoryLimit(32 << 20))
+	limit, err = hh.getMemoryLimit()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(32<<20), limit)
+}
@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+// memEvents is the counters of memory.events in cgroup v2,
+// only OOMKill is available in cgroup v1, which is read from memory.oom_control.
+type memEvents struct {
+	// the number of times memory usage was over memory.high.
+	High uint64
+	// the number of times memory usage was about to go over memory.max.
+	Max uint64
+	// the number of times the memory limit was reached and allocation failed.
+	OOM uint64
+	// the number of processes killed by OOM killer.
+	OOMKill uint64
+}
+
+// increasedSince returns whether any counter increased since prev.
+func (e memEvents) increasedSince(prev memEvents) bool {
+	return e.High > prev.High || e.Max > prev.Max || e.OOM > prev.OOM || e.OOMKill > prev.OOMKill
+}
+
+// cgroupMemStats is the memory stats of the current cgroup.
+type cgroupMemStats struct {
+	// memory usage including page cache, memory.current(v2) or memory.usage_in_bytes(v1).
+	Current uint64
+	// memory limit, memory.max(v2) or memory.limit_in_bytes(v1), 0 means no limit.
+	Max uint64
+	// the inactive page cache, which would be reclaimed before OOM killing.
+	InactiveFile uint64
+	// memory pressure, only available in cgroup v2.
+	Pressure psi
+	Events   memEvents
+}
+
+// workingSet is the memory usage exclude the inactive page cache, the same as kubelet.
+func (s cgroupMemStats) workingSet() uint64 {
+	if s.Current < s.InactiveFile {
+		return 0
+	}
+	return s.Current - s.InactiveFile
+}
+
+// workingSetPercent returns the working set in percent of the memory limit.
+func (s cgroupMemStats) workingSetPercent() int {
+	if s.Max == 0 {
+		return 0
+	}
+	return int(float64(s.workingSet()) / float64(s.Max) * 100)
+}
+
+func getCGroupMemStats() (cgroupMemStats, error) {
+	if isCGroupV2() {
+		return getCGroupV2MemStats()
+	}
+	return getCGroupV1MemStats()
+}
+
+func getCGroupV2MemStats() (cgroupMemStats, error) {
+	var stats cgroupMemStats
+	var err error
+
+	if stats.Current, err = readUint(cgroupV2MemCurrentPath); err != nil {
+		return stats, err
+	}
+	if stats.Max, err = readMax(cgroupV2MemMaxPath); err != nil {
+		return stats, err
+	}
+
+	memStat, err := readFlatKeyed(cgroupV2MemStatPath)
+	if err != nil {
+		return stats, err
+	}
+	stats.InactiveFile = memStat["inactive_file"]
+
+	events, err := readFlatKeyed(cgroupV2MemEventsPath)
+	if err != nil {
+		return stats, err
+	}
+	stats.Events = memEvents{
+		High:    events["high"],
+		Max:     events["max"],
+		OOM:     events["oom"],
+		OOMKill: events["oom_kill"],
+	}
+
+	// memory.pressure is missing when the kernel is not built with PSI.
+	stats.Pressure, _ = readPSI(cgroupV2MemPressurePath)
+	return stats, nil
+}
+
+func getCGroupV1MemStats() (cgroupMemStats, error) {
+	var stats cgroupMemStats
+	var err error
+
+	if stats.Current, err = readUint(cgroupMemUsagePath); err != nil {
+		return stats, err
+	}
+	// it's a huge number when there is no limit, so take the min of it and the machine memory.
+	if stats.Max, err = getCGroupMemoryLimit(); err != nil {
+		return stats, err
+	}
+
+	memStat, err := readFlatKeyed(cgroupMemStatPath)
+	if err != nil {
+		return stats, err
+	}
+	stats.InactiveFile = memStat["total_inactive_file"]
+
+	oomControl, err := readFlatKeyed(cgroupMemOOMControlPath)
+	if err != nil {
+		return stats, err
+	}
+	stats.Events.OOMKill = oomControl["oom_kill"]
+	return stats, nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// metricChecker is the Checker of an application-defined metric, e.g. the request queue length,
+// it dumps the profiles when the metric matches the trigger options.
+type metricChecker struct {
+	h        *Holmes
+	name     string
+	metric   func() float64
+	opts     CheckOptions
+	profiles []string
+}
+
+// RegisterMetric registers the metric to be collected on the CollectInterval ticker along with the built-in checks,
+// when it matches the trigger options, the profiles are dumped in the event "<name>-<n>", and reported with
+// the metric name in Scene.Check. the profiles are some of heap, cpu, threadcreate, goroutine and allocs.
+func (h *Holmes) RegisterMetric(name string, metric func() int, opts CheckOptions, profiles ...string) error {
+	return h.RegisterFloatMetric(name, func() float64 {
+		return float64(metric())
+	}, opts, profiles...)
+}
+
+// RegisterFloatMetric registers the float metric like RegisterMetric, the metric is rounded to compare with
+// the trigger options, scale it in the func when the fraction matters, e.g. in percent.
+func (h *Holmes) RegisterFloatMetric(name string, metric func() float64, opts CheckOptions, profiles ...string) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("no profile to dump for metric %v", name)
+	}
+	for _, profile := range profiles {
+		if _, ok := profile2type[profile]; !ok {
+			return fmt.Errorf("unsupported profile %v for metric %v", profile, name)
+		}
+	}
+
+	return h.RegisterChecker(&metricChecker{
+		h:        h,
+		name:     name,
+		metric:   metric,
+		opts:     opts,
+		profiles: profiles,
+	})
+}
+
+func (c *metricChecker) Name() string {
+	return c.name
+}
+
+// Collect returns the rounded metric, the metric is not available when it panics or isn't a finite number.
+func (c *metricChecker) Collect() (v int, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.h.Errorf("[Holmes] collect metric %v panic: %v", c.name, r)
+			v, ok = 0, false
+		}
+	}()
+
+	f := c.metric()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return int(math.Round(f)), true
+}
+
+func (c *metricChecker) Ring() int {
+	return minCollectCyclesBeforeDumpStart
+}
+
+func (c *metricChecker) Options() CheckOptions {
+	return c.opts
+}
+
+// Dump writes all the profiles, it fails only when none of them is written.
+func (c *metricChecker) Dump(d *DumpContext) error {
+	var errs []string
+	for _, profile := range c.profiles {
+		if _, err := d.WriteProfile(profile); err != nil {
+			c.h.Errorf("[Holmes] failed to dump %v profile for metric %v: %v", profile, c.name, err)
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) == len(c.profiles) {
+		return fmt.Errorf("dump metric %v failed: %v", c.name, strings.Join(errs, "; "))
+	}
+	return nil
+}
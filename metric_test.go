@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterMetric(t *testing.T) {
+	h, err := New()
+	assert.Nil(t, err)
+
+	opts := NewCheckOptions(10, 25, 80, time.Minute)
+	queue := func() int { return 10 }
+	assert.NotNil(t, h.RegisterMetric("queue", queue, opts))
+	assert.NotNil(t, h.RegisterMetric("queue", queue, opts, "block"))
+	assert.Nil(t, h.RegisterMetric("queue", queue, opts, "goroutine"))
+	assert.NotNil(t, h.RegisterMetric("queue", queue, opts, "goroutine"))
+}
+
+func TestMetricCollect(t *testing.T) {
+	h, err := New()
+	assert.Nil(t, err)
+
+	for _, c := range []struct {
+		metric func() float64
+		value  int
+		ok     bool
+	}{
+		{func() float64 { return 2.6 }, 3, true},
+		{func() float64 { return math.NaN() }, 0, false},
+		{func() float64 { return math.Inf(1) }, 0, false},
+		{func() float64 { panic("metric") }, 0, false},
+	} {
+		v, ok := (&metricChecker{h: h, name: "ratio", metric: c.metric}).Collect()
+		assert.Equal(t, c.value, v)
+		assert.Equal(t, c.ok, ok)
+	}
+}
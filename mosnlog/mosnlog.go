@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mosnlog adapts the ErrorLogger of mosn.io/pkg/log to the Logger of holmes,
+// holmes itself doesn't depend on mosn.io/pkg.
+package mosnlog
+
+import (
+	"fmt"
+
+	"mosn.io/holmes"
+	mlog "mosn.io/pkg/log"
+)
+
+type logger struct {
+	logger mlog.ErrorLogger
+}
+
+// NewLogger adapts the ErrorLogger of mosn.io/pkg/log to holmes.Logger,
+// the error logs with holmes.LogKeyAlert are written by Alertf, the fields are appended to the message.
+func NewLogger(l mlog.ErrorLogger) holmes.Logger {
+	return &logger{logger: l}
+}
+
+func (l *logger) Log(level holmes.LogLevel, msg string, keyvals ...interface{}) {
+	var alert string
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == holmes.LogKeyAlert {
+			alert = fmt.Sprint(keyvals[i+1])
+			continue
+		}
+		msg += fmt.Sprintf(" %v=%v", keyvals[i], keyvals[i+1])
+	}
+
+	switch {
+	case level == holmes.LevelError && alert != "":
+		l.logger.Alertf(alert, "%s", msg)
+	case level == holmes.LevelDebug:
+		l.logger.Debugf("%s", msg)
+	case level == holmes.LevelInfo:
+		l.logger.Infof("%s", msg)
+	case level == holmes.LevelWarn:
+		l.logger.Warnf("%s", msg)
+	default:
+		l.logger.Errorf("%s", msg)
+	}
+}
+
+// WithLogger set the logger of mosn.io/pkg/log, nil means disabled.
+// logger can be created by: NewFileLog("/path/to/log/file", level)
+func WithLogger(l mlog.ErrorLogger) holmes.Option {
+	if l == nil {
+		return holmes.WithStructuredLogger(nil)
+	}
+	return holmes.WithStructuredLogger(NewLogger(l))
+}
+
+// NewStdLogger create an ErrorLogger interface value that writing to os.Stdout
+func NewStdLogger() mlog.ErrorLogger {
+	logger, _ := mlog.GetOrCreateLogger("stdout", nil)
+	return &mlog.SimpleErrorLog{
+		Logger: logger,
+		Level:  mlog.DEBUG,
+	}
+}
+
+func NewFileLog(path string, level mlog.Level) mlog.ErrorLogger {
+	logger, _ := mlog.GetOrCreateLogger(path, nil)
+	return &mlog.SimpleErrorLog{
+		Logger: logger,
+		Level:  level,
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosnlog
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mosn.io/holmes"
+	mlog "mosn.io/pkg/log"
+)
+
+type recordErrorLogger struct {
+	mlog.ErrorLogger
+	lines []string
+}
+
+func (l *recordErrorLogger) Alertf(alert string, format string, args ...interface{}) {
+	l.lines = append(l.lines, "alert "+alert+": "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordErrorLogger) Errorf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "error: "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordErrorLogger) Infof(format string, args ...interface{}) {
+	l.lines = append(l.lines, "info: "+fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	rec := &recordErrorLogger{}
+	logger := NewLogger(rec)
+	logger.Log(holmes.LevelError, "goroutine dumped",
+		holmes.LogKeyAlert, "holmes.goroutine", holmes.LogKeyCheck, "goroutine", holmes.LogKeyEventID, "gr-1")
+	logger.Log(holmes.LevelError, "failed")
+	logger.Log(holmes.LevelInfo, "plain", holmes.LogKeyCheck, "mem")
+	assert.Equal(t, []string{
+		"alert holmes.goroutine: goroutine dumped check=goroutine event_id=gr-1",
+		"error: failed",
+		"info: plain check=mem",
+	}, rec.lines)
+
+	_, err := holmes.New(WithLogger(rec))
+	assert.Nil(t, err)
+	// disabled
+	_, err = holmes.New(WithLogger(nil))
+	assert.Nil(t, err)
+}
@@ -18,18 +18,19 @@
 package holmes
 
 import (
+	"fmt"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	mlog "mosn.io/pkg/log"
 )
 
 type options struct {
-	logger mlog.ErrorLogger
+	logger Logger
 
 	UseGoProcAsCPUCore bool // use the go max procs number as the CPU core number when it's true
 	UseCGroup          bool // use the CGroup to calc cpu/memory when it's true
+	UseGoMemLimit      bool // use the go soft memory limit(GOMEMLIMIT) as the memory limit when it's true
 
 	// overwrite the system level memory limitation when > 0.
 	memoryLimit uint64
@@ -51,6 +52,17 @@ type options struct {
 	// cpu sampling time
 	CPUSamplingTime time.Duration
 
+	// write the delta of heap profiles and the top n growing allocation sites when > 0.
+	HeapDiffTopN int
+
+	// the time source and the usage collector of the dump loop.
+	clock     Clock
+	collector Collector
+
+	// append the usage collected in every cycle to the file in json lines when the path is not empty,
+	// which could be replayed by Simulate to tune the trigger rules.
+	metricsRecordOpts *eventLogOptions
+
 	// if write lock is held mean holmes's
 	// configuration is being modified.
 	L *sync.RWMutex
@@ -66,6 +78,25 @@ type options struct {
 	cpuOpts    *typeOption
 	threadOpts *typeOption
 
+	allocOpts *allocOptions
+	fdOpts    *fdOptions
+
+	memPressureOpts *memPressureOptions
+	cpuThrottleOpts *typeOption
+	grLeakOpts      *grLeakOptions
+	baselineOpts    *baselineOptions
+	eventLogOpts    *eventLogOptions
+
+	// the signals to dump the diagnostic profiles on, it's installed on start.
+	dumpSignals []os.Signal
+
+	// dump heap and goroutine ignoring the cooldowns, when the RSS or GC heap reaches
+	// the percent of memory limit, 0 means disabled.
+	criticalPercent int
+
+	// write the stacks of all goroutines to the crash output file in the dump path on fatal error.
+	crashOutput bool
+
 	// profile reporter
 	rptOpts *ReporterOptions
 }
@@ -88,8 +119,15 @@ type DumpOptions struct {
 	DumpPath string
 	// default dump to binary profile, set to true if you want a text profile
 	DumpProfileType dumpProfileType
-	// only dump top 10 if set to false, otherwise dump all, only effective when in_text = true
+	// only dump top n functions if set to false, otherwise dump all, only effective when in_text = true
 	DumpFullStack bool
+	// the top n functions to render, default 10
+	TextTopN int
+	// the sample type to rank the top n functions by, e.g. inuse_space, alloc_space, alloc_objects,
+	// the last sample type of the profile is used when it's empty or not found.
+	TextTopSampleType string
+	// rank the top n functions by the flat or cumulative value, default flat.
+	TextTopSortBy TopSortKey
 	// dump profile to logger. It will make huge log output if enable DumpToLogger option. issues/90
 	DumpToLogger bool
 }
@@ -136,7 +174,11 @@ func (o *options) GetCPUOpts() typeOption {
 func (o *options) GetGrOpts() grOptions {
 	o.L.RLock()
 	defer o.L.RUnlock()
-	return *o.grOpts
+	opts := *o.grOpts
+	// copy the typeOption too, it's modified by Set in place.
+	base := *opts.typeOption
+	opts.typeOption = &base
+	return opts
 }
 
 // GetThreadOpts return a copy of typeOption
@@ -147,6 +189,123 @@ func (o *options) GetThreadOpts() typeOption {
 	return *o.threadOpts
 }
 
+// GetAllocOpts return a copy of allocOptions.
+func (o *options) GetAllocOpts() allocOptions {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	opts := *o.allocOpts
+	// copy the typeOption too, it's modified by Set in place.
+	base := *opts.typeOption
+	opts.typeOption = &base
+	return opts
+}
+
+// GetFDOpts return a copy of fdOptions.
+func (o *options) GetFDOpts() fdOptions {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	opts := *o.fdOpts
+	// copy the typeOption too, it's modified by Set in place.
+	base := *opts.typeOption
+	opts.typeOption = &base
+	return opts
+}
+
+// GetMemPressureOpts return a copy of memPressureOptions.
+func (o *options) GetMemPressureOpts() memPressureOptions {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	opts := *o.memPressureOpts
+	// copy the typeOption too, it's modified by Set in place.
+	base := *opts.typeOption
+	opts.typeOption = &base
+	return opts
+}
+
+// GetCPUThrottleOpts return a copy of typeOption.
+func (o *options) GetCPUThrottleOpts() typeOption {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return *o.cpuThrottleOpts
+}
+
+// GetGrLeakOpts return a copy of grLeakOptions.
+func (o *options) GetGrLeakOpts() grLeakOptions {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return *o.grLeakOpts
+}
+
+// GetCollectInterval return the collect interval.
+func (o *options) GetCollectInterval() time.Duration {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return o.CollectInterval
+}
+
+// GetCPUMaxPercent return the max cpu percent to dump.
+func (o *options) GetCPUMaxPercent() int {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return o.CPUMaxPercent
+}
+
+// GetClock return the clock.
+func (o *options) GetClock() Clock {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return o.clock
+}
+
+// GetCollector return the usage collector.
+func (o *options) GetCollector() Collector {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return o.collector
+}
+
+// GetMetricsRecordOpts return a copy of the options of the metrics record file.
+func (o *options) GetMetricsRecordOpts() eventLogOptions {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return *o.metricsRecordOpts
+}
+
+// GetEventLogOpts return a copy of eventLogOptions.
+func (o *options) GetEventLogOpts() eventLogOptions {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return *o.eventLogOpts
+}
+
+// GetCriticalPercent return the critical percent of memory limit.
+func (o *options) GetCriticalPercent() int {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return o.criticalPercent
+}
+
+// GetCrashOutput return whether the crash output is enabled.
+func (o *options) GetCrashOutput() bool {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return o.crashOutput
+}
+
+// GetDumpSignals return a copy of the dump signals.
+func (o *options) GetDumpSignals() []os.Signal {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return append([]os.Signal(nil), o.dumpSignals...)
+}
+
+// GetBaselineOpts return a copy of baselineOptions.
+func (o *options) GetBaselineOpts() baselineOptions {
+	o.L.RLock()
+	defer o.L.RUnlock()
+	return *o.baselineOpts
+}
+
 // GetGcHeapOpts return a copy of typeOption
 // if gCHeapOpts not exist return a empty typeOption and false.
 func (o *options) GetGcHeapOpts() typeOption {
@@ -168,12 +327,22 @@ func (f optionFunc) apply(opts *options) error {
 
 func newOptions() *options {
 	o := &options{
-		logger:            NewStdLogger(),
+		logger:            newDefaultLogger(),
 		grOpts:            newGrOptions(),
 		memOpts:           newMemOptions(),
 		gCHeapOpts:        newGCHeapOptions(),
 		cpuOpts:           newCPUOptions(),
 		threadOpts:        newThreadOptions(),
+		allocOpts:         newAllocOptions(),
+		fdOpts:            newFDOptions(),
+		memPressureOpts:   newMemPressureOptions(),
+		cpuThrottleOpts:   newCPUThrottleOptions(),
+		grLeakOpts:        newGrLeakOptions(),
+		baselineOpts:      newBaselineOptions(),
+		eventLogOpts:      newEventLogOptions(),
+		metricsRecordOpts: newEventLogOptions(),
+		clock:             realClock{},
+		collector:         processCollector{},
 		CollectInterval:   defaultInterval,
 		intervalResetting: make(chan struct{}, 1),
 		CPUSamplingTime:   defaultCPUSamplingTime,
@@ -181,6 +350,8 @@ func newOptions() *options {
 			DumpPath:        defaultDumpPath,
 			DumpProfileType: defaultDumpProfileType,
 			DumpFullStack:   false,
+			TextTopN:        TrimResultTopN,
+			TextTopSortBy:   TopSortByFlat,
 		},
 		ShrinkThrOptions: &ShrinkThrOptions{
 			Enable: false,
@@ -191,9 +362,9 @@ func newOptions() *options {
 	return o
 }
 
-// WithLogger set the logger
-// logger can be created by: NewFileLog("/path/to/log/file", level)
-func WithLogger(logger mlog.ErrorLogger) Option {
+// WithStructuredLogger set the logger with structured fields of the check, reason and event ID, nil means disabled.
+// logger can be created by: NewSlogLogger, NewStdLogAdapter or mosnlog.NewLogger, default writes to os.Stdout.
+func WithStructuredLogger(logger Logger) Option {
 	return optionFunc(func(opts *options) (err error) {
 		opts.logger = logger
 		return
@@ -254,6 +425,48 @@ func WithCPUSamplingTime(duration string) Option {
 	})
 }
 
+// WithHeapDiff set to write the delta profile between heap dumps and the top n growing allocation sites, n <= 0 means disabled.
+// the GC heap dump is diffed with the previous one of the same event, both mem and GC heap dump are diffed with
+// the baseline heap profile captured after warming up.
+func WithHeapDiff(n int) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.HeapDiffTopN = n
+		return
+	})
+}
+
+// WithClock set the time source of the dump loop, the cooldowns and the reports, e.g. holmestest.Clock in tests.
+// Notice: it only takes effect before Start.
+func WithClock(clock Clock) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if clock == nil {
+			return fmt.Errorf("clock must not be nil")
+		}
+		opts.clock = clock
+		return
+	})
+}
+
+// WithCollector set the collector of the cpu, mem, goroutine and thread usage, e.g. holmestest.Collector in tests.
+func WithCollector(collector Collector) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if collector == nil {
+			return fmt.Errorf("collector must not be nil")
+		}
+		opts.collector = collector
+		return
+	})
+}
+
+// WithMetricsRecord set to append the cpu, mem, goroutine and thread usage collected in every cycle
+// to the file in json lines, which could be replayed by Simulate, empty path means disabled.
+// it's rotated like WithEventLog when exceeds maxSize bytes, <= 0 means the default 5m, and keeps maxBackups rotated files.
+func WithMetricsRecord(path string, maxSize int64, maxBackups int) Option {
+	return optionFunc(func(opts *options) (err error) {
+		return opts.metricsRecordOpts.set("metrics record", path, maxSize, maxBackups)
+	})
+}
+
 // WithBinaryDump set dump mode to binary.
 func WithBinaryDump() Option {
 	return withDumpProfileType(binaryDump)
@@ -264,7 +477,8 @@ func WithTextDump() Option {
 	return withDumpProfileType(textDump)
 }
 
-// WithFullStack set to dump full stack or top 10 stack, when dump in text mode.
+// WithFullStack set to dump full stack or top n functions, when dump in text mode.
+// the top n report is configured by WithTextTop.
 func WithFullStack(isFull bool) Option {
 	return optionFunc(func(opts *options) (err error) {
 		opts.DumpFullStack = isFull
@@ -285,6 +499,9 @@ type grOptions struct {
 	//   2. goroutine_num > GoroutineTriggerNumAbsNum && goroutine_num < GoroutineTriggerNumMax
 	*typeOption
 	GoroutineTriggerNumMax int // goroutine trigger max in number
+	// summarize the goroutine dump by grouping goroutines with identical stack and state,
+	// only keep the top n groups, 0 means disabled.
+	SummaryTopN int
 }
 
 func newGrOptions() *grOptions {
@@ -306,6 +523,177 @@ func WithGoroutineDump(min int, diff int, abs int, max int, coolDown time.Durati
 	})
 }
 
+// WithGoroutineSummary set to summarize the goroutine dump into the top n groups of
+// goroutines with identical stack and state, sorted by count, n <= 0 means disabled.
+// the summary is written to a separated file and reported in Scene.GoroutineGroups.
+// Notice: it takes an extra goroutine dump with debug=2.
+func WithGoroutineSummary(n int) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if n < 0 {
+			n = 0
+		}
+		opts.grOpts.SummaryTopN = n
+		return
+	})
+}
+
+type grLeakOptions struct {
+	// enable the goroutine leak detector, it takes a goroutine snapshot every Interval,
+	// and reports the goroutine groups with identical stack and state as leaked when
+	//   1. the group count grows in each of the recent GrowthCycles snapshots
+	//   2. the longest wait of the group is at least MinWait
+	// it's independent of the goroutine number trigger.
+	Enable       bool
+	Interval     time.Duration
+	GrowthCycles int
+	MinWait      time.Duration
+	CoolDown     time.Duration
+}
+
+func newGrLeakOptions() *grLeakOptions {
+	return &grLeakOptions{
+		Interval:     defaultGoroutineLeakInterval,
+		GrowthCycles: defaultGoroutineLeakGrowthCycles,
+		MinWait:      defaultGoroutineLeakMinWait,
+		CoolDown:     defaultGoroutineCoolDown,
+	}
+}
+
+// WithGoroutineLeakDump set the goroutine leak detector options.
+// Notice: each snapshot is a goroutine dump with debug=2, don't make the interval too short.
+func WithGoroutineLeakDump(interval time.Duration, growthCycles int, minWait time.Duration, coolDown time.Duration) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if growthCycles <= 0 {
+			return fmt.Errorf("goroutine leak growth cycles must be positive, got %v", growthCycles)
+		}
+		opts.grLeakOpts.Interval = interval
+		opts.grLeakOpts.GrowthCycles = growthCycles
+		opts.grLeakOpts.MinWait = minWait
+		opts.grLeakOpts.CoolDown = coolDown
+		return
+	})
+}
+
+type baselineOptions struct {
+	// enable to capture the heap, goroutine and cpu profiles after warming up as the baseline,
+	// every triggered dump of these types is diffed with the latest baseline.
+	Enable bool
+	// the baseline is refreshed when no dump happens in the recent RefreshInterval,
+	// so it always represents a quiet period, <= 0 means never refresh.
+	RefreshInterval time.Duration
+}
+
+func newBaselineOptions() *baselineOptions {
+	return &baselineOptions{
+		RefreshInterval: defaultBaselineRefreshInterval,
+	}
+}
+
+// WithBaselineProfiles set to capture the baseline profiles after warming up, and refresh them
+// in the quiet periods of refreshInterval. the top n of the diffs is set by WithHeapDiff, default 10.
+func WithBaselineProfiles(refreshInterval time.Duration) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.baselineOpts.Enable = true
+		opts.baselineOpts.RefreshInterval = refreshInterval
+		return
+	})
+}
+
+// eventLogOptions is the options of the file in json lines rotated by size, the event log or the metrics record.
+type eventLogOptions struct {
+	// append the lines to the file, empty means disabled.
+	Path string
+	// the file is rotated when it exceeds MaxSize in bytes, default 5m.
+	MaxSize int64
+	// keep at most MaxBackups rotated files named Path.1, Path.2 and so on, 0 means no backup.
+	MaxBackups int
+}
+
+// set sets the path and the rotation of the file, name is the file in the error.
+func (o *eventLogOptions) set(name string, path string, maxSize int64, maxBackups int) error {
+	if maxBackups < 0 {
+		return fmt.Errorf("%v max backups must not be negative, got %v", name, maxBackups)
+	}
+	if maxSize <= 0 {
+		maxSize = defaultShardLoggerSize
+	}
+	o.Path = path
+	o.MaxSize = maxSize
+	o.MaxBackups = maxBackups
+	return nil
+}
+
+func newEventLogOptions() *eventLogOptions {
+	return &eventLogOptions{
+		MaxSize:    defaultShardLoggerSize,
+		MaxBackups: defaultEventLogBackups,
+	}
+}
+
+// WithEventLog set to append the inputs, decision, reason, cooldown and dumped files of every check
+// to the file in json lines, the NODUMP logs are lowered to debug level then.
+// it's rotated when exceeds maxSize bytes, <= 0 means the default 5m, and keeps maxBackups rotated files.
+// the file is kept open between the writes and closed by Shutdown.
+func WithEventLog(path string, maxSize int64, maxBackups int) Option {
+	return optionFunc(func(opts *options) (err error) {
+		return opts.eventLogOpts.set("event log", path, maxSize, maxBackups)
+	})
+}
+
+// WithSignalDump set to dump the goroutine, heap, threadcreate and cpu profiles in an event when any of the signals
+// is received, SIGUSR1 by default, e.g. by `kill -USR1 <pid>`. the signal handler is installed on start,
+// and removed on stop, it's taken effect on the next start when set on fly.
+func WithSignalDump(sigs ...os.Signal) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if len(sigs) == 0 {
+			sigs = defaultDumpSignals
+		}
+		if len(sigs) == 0 {
+			return fmt.Errorf("no default dump signal on this platform")
+		}
+		opts.dumpSignals = sigs
+		return
+	})
+}
+
+// WithCriticalDump set the critical percent of memory limit, when the RSS or the GC heap reaches it,
+// holmes dumps heap and goroutine profiles at once, ignoring the cooldowns and CPUMaxPercent,
+// and syncs them to disk before the process may be killed by OOM.
+// it dumps once until both of them drop below the percent, 0 means disabled.
+func WithCriticalDump(percent int) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("invalid critical percent %v, should be in [0, 100]", percent)
+		}
+		opts.criticalPercent = percent
+		return
+	})
+}
+
+// WithCrashOutput set to print the stacks of all goroutines on fatal error by debug.SetTraceback("all"),
+// and append the crash output to crash.log in the dump path besides stderr, which requires go1.23.
+// it's taken effect on start, and kept after stop, since it's the setting of the process.
+func WithCrashOutput() Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.crashOutput = true
+		return
+	})
+}
+
+// WithTextTop set the top n report written in text mode when not dumping full stack,
+// the heap, goroutine and threadcreate profiles are ranked by the flat or cumulative value of sampleType.
+func WithTextTop(n int, sampleType string, sortBy TopSortKey) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if n <= 0 {
+			return fmt.Errorf("text top n must be positive, got %v", n)
+		}
+		opts.TextTopN = n
+		opts.TextTopSampleType = sampleType
+		opts.TextTopSortBy = sortBy
+		return
+	})
+}
+
 func WithDumpToLogger(new bool) Option {
 	return optionFunc(func(opts *options) (err error) {
 		opts.DumpToLogger = new
@@ -386,6 +774,124 @@ func WithGCHeapDump(min int, diff int, abs int, coolDown time.Duration) Option {
 	})
 }
 
+type allocOptions struct {
+	// enable the allocation rate dumper, should dump if one of the following requirements is matched
+	//   1. alloc rate > TriggerMin && alloc rate diff > TriggerDiff
+	//   2. alloc rate > TriggerAbs
+	// in MB/s.
+	*typeOption
+	// the sampling time of the cpu profile dumped along with the allocs profile.
+	CPUSamplingTime time.Duration
+}
+
+func newAllocOptions() *allocOptions {
+	base := newTypeOpts(
+		defaultAllocRateTriggerMin,
+		defaultAllocRateTriggerAbs,
+		defaultAllocRateTriggerDiff,
+		defaultCooldown,
+	)
+	return &allocOptions{typeOption: base, CPUSamplingTime: defaultAllocRateCPUSamplingTime}
+}
+
+// WithAllocRateDump set the allocation rate dump options, min and abs are in MB/s.
+// cpuSamplingTime is the sampling time of the cpu profile dumped along with the allocs profile,
+// no cpu profile would be dumped when it <= 0.
+func WithAllocRateDump(min int, diff int, abs int, cpuSamplingTime time.Duration, coolDown time.Duration) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.allocOpts.Set(min, abs, diff, coolDown)
+		opts.allocOpts.CPUSamplingTime = cpuSamplingTime
+		return
+	})
+}
+
+type fdOptions struct {
+	// enable the fd dumper, should dump if one of the following requirements is matched
+	//   1. fd usage > TriggerMin && fd usage diff > TriggerDiff
+	//   2. fd usage > TriggerAbs
+	//   3. fd number > NumAbs
+	// fd usage is the percent of open fds to the soft limit of RLIMIT_NOFILE.
+	*typeOption
+	// the abs number of open fds, 0 means disabled.
+	NumAbs int
+	// track the tcp states of the socket fds in each cycle, by reading /proc/self/net/tcp and tcp6,
+	// and attach them to the fd dump.
+	TCPStates bool
+}
+
+func newFDOptions() *fdOptions {
+	base := newTypeOpts(
+		defaultFDTriggerMin,
+		defaultFDTriggerAbs,
+		defaultFDTriggerDiff,
+		defaultCooldown,
+	)
+	return &fdOptions{typeOption: base}
+}
+
+// WithFDDump set the fd dump options, in percent of the soft limit of RLIMIT_NOFILE.
+// it only works on linux since it relies on procfs.
+// Notice: go1.19+ raises the soft limit to the hard limit on start, which is often 1M or more,
+// so the percent may never be reached, use WithFDNumAbs to dump by the number of open fds.
+func WithFDDump(min int, diff int, abs int, coolDown time.Duration) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.fdOpts.Set(min, abs, diff, coolDown)
+		return
+	})
+}
+
+// WithFDNumAbs set the fd dump to happen when the number of open fds > n, besides the percent rules, 0 means disabled.
+func WithFDNumAbs(n int) Option {
+	return optionFunc(func(opts *options) (err error) {
+		if n < 0 {
+			return fmt.Errorf("invalid fd number abs %v", n)
+		}
+		opts.fdOpts.NumAbs = n
+		return
+	})
+}
+
+// WithFDTCPStates set whether track the tcp states of the socket fds in each cycle, and attach them to the fd dump.
+func WithFDTCPStates(enabled bool) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.fdOpts.TCPStates = enabled
+		return
+	})
+}
+
+type memPressureOptions struct {
+	// enable the memory pressure dumper, should dump if one of the following requirements is matched
+	//   1. working set > TriggerMin && working set diff > TriggerDiff
+	//   2. working set > TriggerAbs
+	//   3. memory pressure some avg10 > PressureAbs
+	//   4. any of the high, max, oom, oom_kill counter of memory.events increased
+	// working set is the cgroup memory usage exclude the inactive page cache, in percent of the memory limit.
+	*typeOption
+	// memory pressure some avg10 trigger abs in percent, 0 means disabled, only available in cgroup v2.
+	PressureAbs int
+}
+
+func newMemPressureOptions() *memPressureOptions {
+	base := newTypeOpts(
+		defaultMemPressureTriggerMin,
+		defaultMemPressureTriggerAbs,
+		defaultMemPressureTriggerDiff,
+		defaultCooldown,
+	)
+	return &memPressureOptions{typeOption: base, PressureAbs: defaultMemPressureAbs}
+}
+
+// WithMemPressureDump set the memory pressure dump options.
+// min, diff, abs are in percent of the cgroup working set to the memory limit,
+// pressureAbs is in percent of the memory pressure some avg10.
+func WithMemPressureDump(min int, diff int, abs int, pressureAbs int, coolDown time.Duration) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.memPressureOpts.Set(min, abs, diff, coolDown)
+		opts.memPressureOpts.PressureAbs = pressureAbs
+		return
+	})
+}
+
 // WithCPUCore overwrite the system level CPU core number when it > 0.
 // it's not a good idea to modify it on fly since it affects the CPU percent caculation.
 func WithCPUCore(cpuCore float64) Option {
@@ -403,6 +909,16 @@ func WithMemoryLimit(limit uint64) Option {
 	})
 }
 
+// WithGoMemLimit set holmes use the go soft memory limit(GOMEMLIMIT or debug.SetMemoryLimit)
+// as the memory limit instead of the cgroup or host memory.
+// it only works since go1.19, and falls back to the cgroup or host memory when there is no limit.
+func WithGoMemLimit(enabled bool) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.UseGoMemLimit = enabled
+		return
+	})
+}
+
 func newThreadOptions() *typeOption {
 	return newTypeOpts(
 		defaultThreadTriggerMin,
@@ -444,6 +960,29 @@ func WithCPUDump(min int, diff int, abs int, coolDown time.Duration) Option {
 	})
 }
 
+// newCPUThrottleOptions
+// enable the cpu throttle dumper, should dump if one of the following requirements is matched
+//  1. throttled periods > TriggerMin && throttled periods diff > TriggerDiff
+//  2. throttled periods > TriggerAbs
+//
+// in percent of the cfs periods elapsed in the collect interval.
+func newCPUThrottleOptions() *typeOption {
+	return newTypeOpts(
+		defaultCPUThrottleTriggerMin,
+		defaultCPUThrottleTriggerAbs,
+		defaultCPUThrottleTriggerDiff,
+		defaultCooldown,
+	)
+}
+
+// WithCPUThrottleDump set the cpu throttle dump options, in percent of throttled cfs periods.
+func WithCPUThrottleDump(min int, diff int, abs int, coolDown time.Duration) Option {
+	return optionFunc(func(opts *options) (err error) {
+		opts.cpuThrottleOpts.Set(min, abs, diff, coolDown)
+		return
+	})
+}
+
 // WithGoProcAsCPUCore set holmes use cgroup or not.
 func WithGoProcAsCPUCore(enabled bool) Option {
 	return optionFunc(func(opts *options) (err error) {
@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"runtime/pprof"
+)
+
+// the output of the original stack of the recovered panic, since it's not shown by the panic again.
+var panicOutput io.Writer = os.Stderr
+
+// RecoverAndDump writes the stacks of all goroutines and the heap profile on panic, then panics again with the
+// recovered value, it must be deferred directly, e.g. defer h.RecoverAndDump().
+// the original stack of the panic is printed to stderr before panicking again.
+// the files are synced to disk, while the reports may be lost when the process exits.
+func (h *Holmes) RecoverAndDump() {
+	if r := recover(); r != nil {
+		// capture it before dumping, it's the panicking goroutine still.
+		stack := debug.Stack()
+		eventID := h.panicDump(r)
+		fmt.Fprintf(panicOutput, "panic: %v [recovered by holmes, dumped in %v]\n\n%s\n", r, eventID, stack) // nolint: errcheck
+		panic(r)
+	}
+}
+
+// Go runs f in a new goroutine, and dumps by RecoverAndDump when f panics.
+func (h *Holmes) Go(f func()) {
+	go func() {
+		defer h.RecoverAndDump()
+		f()
+	}()
+}
+
+// panicDump writes the stacks of all goroutines including the panicking one, and the heap profile in an event,
+// returns the event ID.
+func (h *Holmes) panicDump(r interface{}) string {
+	state := h.state(panicDump)
+	check := check2name[panicDump]
+	now := h.now()
+	seq := state.next(now, state.coolDownUntil())
+	d := &DumpContext{
+		h:       h,
+		ctx:     context.Background(),
+		Check:   check,
+		EventID: fmt.Sprintf("%s-%d", check, seq),
+		Reason:  ReasonPanic,
+		Scene:   Scene{Check: check},
+		fsync:   true,
+	}
+	h.Errorf("[Holmes] panic: %v, dump %v", r, d.EventID)
+
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 2) // nolint: errcheck
+	if fileName, data := h.writeProfileData(buf, goroutineStack, d.EventID, true); fileName != "" {
+		d.report(type2name[goroutine], fileName, data)
+	}
+
+	if _, err := h.dumpEvent(d, []string{"heap"}); err != nil {
+		h.Errorf("[Holmes] dump on panic failed: %v", err)
+	}
+	return d.EventID
+}
+
+// initCrashOutput prints the stacks of all goroutines on fatal error,
+// and appends the crash output to the crash output file in the dump path.
+func (h *Holmes) initCrashOutput() {
+	debug.SetTraceback("all")
+
+	dumpPath := h.opts.DumpPath
+	if err := os.MkdirAll(dumpPath, 0o755); err != nil {
+		h.Errorf("[Holmes] failed to create dump path %v: %v", dumpPath, err)
+		return
+	}
+	fileName := filepath.Join(dumpPath, defaultCrashOutputName)
+	f, err := os.OpenFile(fileName, defaultLoggerFlags, defaultLoggerPerm)
+	if err != nil {
+		h.Errorf("[Holmes] failed to open crash output %v: %v", fileName, err)
+		return
+	}
+	// the file is duplicated by the runtime.
+	defer f.Close() // nolint: errcheck
+
+	if err := setCrashOutput(f); err != nil {
+		h.Errorf("[Holmes] failed to set crash output %v: %v", fileName, err)
+		return
+	}
+	h.Infof("[Holmes] crash output is written to %v", fileName)
+}
@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverAndDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-panic")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "events.log")
+
+	h, err := New(WithDumpPath(dir), WithEventLog(path, 0, 1))
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	panicOutput = &output
+	defer func() { panicOutput = os.Stderr }()
+
+	func() {
+		defer func() {
+			assert.Equal(t, "boom", recover())
+		}()
+		defer h.RecoverAndDump()
+		panic("boom")
+	}()
+
+	events := readCheckEvents(t, path)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "panic", events[0].Check)
+	assert.Equal(t, "panic-0", events[0].EventID)
+	assert.Equal(t, ReasonPanic.String(), events[0].Reason)
+	assert.Equal(t, 2, len(events[0].Files))
+
+	// the stacks of all goroutines, including the panicking one.
+	stacks := events[0].Files[0]
+	assert.True(t, strings.HasPrefix(filepath.Base(stacks), "goroutinestack.panic-0."))
+	data, err := ioutil.ReadFile(stacks)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "TestRecoverAndDump")
+
+	// the original stack of the panic.
+	assert.True(t, strings.HasPrefix(output.String(), "panic: boom [recovered by holmes, dumped in panic-0]"))
+	assert.Contains(t, output.String(), "TestRecoverAndDump")
+
+	// no panic, no dump.
+	func() {
+		defer h.RecoverAndDump()
+	}()
+	assert.Equal(t, 1, h.state(panicDump).count())
+}
+
+// recordReporter records the profile types of the reports.
+type recordReporter struct {
+	mu     sync.Mutex
+	pTypes []string
+}
+
+func (r *recordReporter) Report(pType string, filename string, reason ReasonType, eventID string,
+	sampleTime time.Time, pprofBytes []byte, scene Scene) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pTypes = append(r.pTypes, pType)
+	return nil
+}
+
+func TestPanicDumpReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-panic")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	r := &recordReporter{}
+	h, err := New(WithCollectInterval("1h"), WithDumpPath(dir), WithProfileReporter(r))
+	assert.Nil(t, err)
+	h.Start()
+
+	panicOutput = ioutil.Discard
+	defer func() { panicOutput = os.Stderr }()
+	func() {
+		defer func() {
+			assert.Equal(t, "boom", recover())
+		}()
+		defer h.RecoverAndDump()
+		panic("boom")
+	}()
+
+	// both the goroutine stacks and the heap profile are reported.
+	assert.Nil(t, h.Shutdown(context.Background()))
+	assert.Equal(t, []string{"goroutine", "heap"}, r.pTypes)
+}
+
+func TestCrashOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-crash")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	h, err := New(WithDumpPath(dir), WithCrashOutput())
+	assert.Nil(t, err)
+	h.initEnvironment()
+	defer setCrashOutput(nil) // nolint: errcheck
+
+	_, err = os.Stat(filepath.Join(dir, defaultCrashOutputName))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+
+	"github.com/google/pprof/profile"
+
+	"mosn.io/holmes/internal/inspect"
+)
+
+// TopSortKey is the value to rank the functions in the top n report.
+type TopSortKey = inspect.SortKey
+
+const (
+	// TopSortByFlat ranks by the value of the function itself.
+	TopSortByFlat = inspect.SortByFlat
+	// TopSortByCum ranks by the value of the function and its callees.
+	TopSortByCum = inspect.SortByCum
+)
+
+// renderTextTop renders the captured binary profile as a top n report like `go tool pprof -top`,
+// the data is returned as is when the profile could not be rendered.
+func renderTextTop(data bytes.Buffer, dumpOpts *DumpOptions) []byte {
+	p, err := profile.ParseData(data.Bytes())
+	if err != nil || len(p.SampleType) == 0 {
+		return data.Bytes()
+	}
+	top := inspect.FormatTop(p, dumpOpts.TextTopSampleType, dumpOpts.TextTopN, dumpOpts.TextTopSortBy)
+	return top.Bytes()
+}
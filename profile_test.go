@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestHeapProfile builds a heap profile, values are the inuse_space of the leaf functions.
+func newTestHeapProfile(t *testing.T, values map[string]int64) []byte {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "inuse_objects", Unit: "count"}, {Type: "inuse_space", Unit: "bytes"}},
+		PeriodType: &profile.ValueType{Type: "space", Unit: "bytes"},
+	}
+	main := &profile.Function{ID: 1, Name: "main.main", Filename: "main.go"}
+	mainLoc := &profile.Location{ID: 1, Line: []profile.Line{{Function: main, Line: 10}}}
+	p.Function = append(p.Function, main)
+	p.Location = append(p.Location, mainLoc)
+
+	id := uint64(2)
+	for _, name := range []string{"main.leak", "main.stable"} {
+		v, ok := values[name]
+		if !ok {
+			continue
+		}
+		fn := &profile.Function{ID: id, Name: name, Filename: "main.go"}
+		loc := &profile.Location{ID: id, Line: []profile.Line{{Function: fn, Line: int64(id)}}}
+		p.Function = append(p.Function, fn)
+		p.Location = append(p.Location, loc)
+		p.Sample = append(p.Sample, &profile.Sample{Location: []*profile.Location{loc, mainLoc}, Value: []int64{1, v}})
+		id++
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, p.Write(&buf))
+	return buf.Bytes()
+}
+
+func TestRenderTextTop(t *testing.T) {
+	opts := newOptions()
+	// rendered from the captured profile.
+	leaked := newTestHeapProfile(t, map[string]int64{"main.leak": 1})
+	buf := renderTextTop(*bytes.NewBuffer(leaked), opts.DumpOptions)
+	assert.Contains(t, string(buf), "Type: inuse_space\nShowing top 2 of 2 functions")
+	assert.Contains(t, string(buf), "main.leak")
+
+	data := bytes.NewBufferString("not a profile")
+	assert.Equal(t, []byte("not a profile"), renderTextTop(*data, opts.DumpOptions))
+
+	// the profiles rendered as top n are captured in binary.
+	opts.DumpProfileType = textDump
+	assert.Equal(t, int(binaryDump), opts.profileDebug(goroutine))
+	assert.Equal(t, int(textDump), opts.profileDebug(allocRate))
+	opts.DumpFullStack = true
+	assert.Equal(t, int(textDump), opts.profileDebug(goroutine))
+}
+
+func TestWriteProfileDataTextTop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-texttop")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	rec := &recordLogger{}
+	h, err := New(WithStructuredLogger(rec), WithDumpPath(dir), WithTextDump(), WithDumpToLogger(true))
+	assert.Nil(t, err)
+
+	leaked := newTestHeapProfile(t, map[string]int64{"main.leak": 1})
+	fileName, data := h.writeProfileDataToFile(*bytes.NewBuffer(leaked), mem, "mem-0")
+	assert.Contains(t, string(data), "Showing top 2 of 2 functions")
+
+	// the rendered report is written, logged and returned to report.
+	written, err := ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, data, written)
+	assert.Equal(t, "[Holmes] mem profile: \n"+string(data), rec.records[0].msg)
+}
@@ -1,6 +1,10 @@
 package holmes
 
-import "time"
+import (
+	"time"
+
+	"mosn.io/holmes/internal/inspect"
+)
 
 type ProfileReporter interface {
 	Report(pType string, filename string, reason ReasonType, eventID string, sampleTime time.Time, pprofBytes []byte, scene Scene) error
@@ -17,15 +21,39 @@ type rptEvent struct {
 	Scene      Scene
 }
 
+// GoroutineGroup is a group of goroutines with identical stack and state,
+// parsed from the goroutine dump with debug=2.
+type GoroutineGroup = inspect.GoroutineGroup
+
 // Scene contains the scene information when profile triggers,
 // including current value, average value and configurations.
 type Scene struct {
 	typeOption
 
+	// Check is the name of the check triggered the dump, e.g. mem, or the name of a custom metric.
+	Check string
 	// current value while dump event occurs
 	CurVal int
 	// Avg is the average of the past values
 	Avg int
+	// Hint is the top functions of the sampled cpu profile, only set for the cpu and cpu throttle dumps,
+	// since their alerts are logged before sampling.
+	Hint string
+	// Critical is true when the dump is triggered in the critical mode, the process may be killed by OOM soon.
+	Critical bool
+
+	// CPUThrottling is the cfs throttling stats in the collect interval,
+	// only set when the dump is triggered by cpu throttling.
+	CPUThrottling *CPUThrottlingStats
+
+	// GoroutineGroups is the top goroutine groups with identical stack and state,
+	// only set for goroutine dump when goroutine summary is enabled,
+	// or the leaking goroutine groups when the dump is triggered by goroutine leak.
+	GoroutineGroups []GoroutineGroup
+
+	// TCPStates is the number of tcp sockets by state, e.g. CLOSE_WAIT,
+	// only set for fd dump when the tcp states are enabled.
+	TCPStates map[string]int
 }
 
 type ReasonType uint8
@@ -43,6 +71,24 @@ const (
 	ReasonCurGreaterAbs
 	// ReasonDiff means current value is greater than the value: (diff + 1) * agv.
 	ReasonDiff
+	// ReasonPressureGreaterAbs means the memory pressure stall is greater than the pressure abs value.
+	ReasonPressureGreaterAbs
+	// ReasonMemoryEvents means any of the high, max, oom or oom_kill counter of memory.events increased.
+	ReasonMemoryEvents
+	// ReasonGoroutineLeak means some goroutine groups grow monotonically with long wait.
+	ReasonGoroutineLeak
+	// ReasonResend means the stored dump is sent again, e.g. by the holmes command.
+	ReasonResend
+	// ReasonManual means the dump is requested by Holmes.Dump.
+	ReasonManual
+	// ReasonSignal means the dump is requested by the dump signal.
+	ReasonSignal
+	// ReasonCritical means the RSS or the GC heap reaches the critical percent of the memory limit.
+	ReasonCritical
+	// ReasonPanic means the dump is written by Holmes.RecoverAndDump on panic.
+	ReasonPanic
+	// ReasonFDNumGreaterAbs means the open fd number is greater than the fd number abs value.
+	ReasonFDNumGreaterAbs
 )
 
 func (rt ReasonType) String() string {
@@ -58,6 +104,24 @@ func (rt ReasonType) String() string {
 		reason = "curVal > ruleAbs"
 	case ReasonDiff:
 		reason = "curVal >= ruleMin, and meet diff trigger condition"
+	case ReasonPressureGreaterAbs:
+		reason = "memory pressure > rulePressureAbs"
+	case ReasonMemoryEvents:
+		reason = "memory events high/max/oom/oom_kill increased"
+	case ReasonGoroutineLeak:
+		reason = "goroutine groups grow monotonically with long wait"
+	case ReasonResend:
+		reason = "resend the stored dump"
+	case ReasonManual:
+		reason = "manual dump"
+	case ReasonSignal:
+		reason = "dump on signal"
+	case ReasonCritical:
+		reason = "curVal >= critical percent of memory limit"
+	case ReasonPanic:
+		reason = "recovered panic"
+	case ReasonFDNumGreaterAbs:
+		reason = "fd number > ruleNumAbs"
 
 	}
 
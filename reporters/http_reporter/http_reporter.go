@@ -71,7 +71,10 @@ func (r *HttpReporter) Report(ptype string, filename string, reason holmes.Reaso
 	writer.WriteField("profile_type", ptype)      // nolint: errcheck
 	writer.WriteField("event_id", eventID)        // nolint: errcheck
 	writer.WriteField("comment", reason.String()) // nolint: errcheck
-	writer.Close()                                // nolint: errcheck
+	if scene.Check != "" {
+		writer.WriteField("check", scene.Check) // nolint: errcheck
+	}
+	writer.Close() // nolint: errcheck
 	request, err := http.NewRequest("POST", r.url, body)
 	if err != nil {
 		return fmt.Errorf("NewRequest err: %v", err)
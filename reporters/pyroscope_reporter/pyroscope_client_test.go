@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"mosn.io/holmes"
+	"mosn.io/holmes/mosnlog"
 )
 
 var h *holmes.Holmes
@@ -39,7 +40,7 @@ func TestPyroscopeClient(t *testing.T) {
 	tags := map[string]string{
 		"region": "zh",
 	}
-	pReporter, err := NewPyroscopeReporter("holmes-client-01", tags, cfg, holmes.NewStdLogger())
+	pReporter, err := NewPyroscopeReporter("holmes-client-01", tags, cfg, mosnlog.NewStdLogger())
 	if err != nil {
 		log.Fatalf("NewPyroscopeReporter error %v", err)
 	}
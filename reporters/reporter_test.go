@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -43,11 +45,27 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-var grReportCount int
-var cpuReportCount int
+// the counters and errors are written by the reporter goroutine of holmes.
+var grReportCount int32
+var cpuReportCount int32
+
+var errMu sync.Mutex
 var unknownReasonTypeErr error
 var sceneException error
 
+func setErr(p *error, err error) error {
+	errMu.Lock()
+	defer errMu.Unlock()
+	*p = err
+	return err
+}
+
+func getErr(p *error) error {
+	errMu.Lock()
+	defer errMu.Unlock()
+	return *p
+}
+
 type mockReporter struct {
 }
 
@@ -57,32 +75,29 @@ func (m *mockReporter) Report(pType string, filename string, reason holmes.Reaso
 	// read filename
 	switch pType {
 	case "goroutine":
-		grReportCount++
+		atomic.AddInt32(&grReportCount, 1)
 	case "cpu":
-		cpuReportCount++
+		atomic.AddInt32(&cpuReportCount, 1)
 
 	}
 
 	if len(reason.String()) == 0 { // unknown reason type
-		unknownReasonTypeErr = fmt.Errorf("reporter: unknown reason type")
-		return unknownReasonTypeErr
+		return setErr(&unknownReasonTypeErr, fmt.Errorf("reporter: unknown reason type"))
 	}
 
 	{ // test scene
 		errPrefix := "reporter: scene exception ==> "
 		if scene.TriggerAbs == 0 {
-			sceneException = fmt.Errorf(errPrefix + "abs in configuration is 0")
-			return sceneException
+			return setErr(&sceneException, fmt.Errorf(errPrefix+"abs in configuration is 0"))
 		}
 		if scene.TriggerDiff == 0 {
-			sceneException = fmt.Errorf(errPrefix + "diff in configuration is 0")
-			return sceneException
+			return setErr(&sceneException, fmt.Errorf(errPrefix+"diff in configuration is 0"))
 		}
 	}
 	return nil
 }
 
-var grReopenReportCount int
+var grReopenReportCount int32
 
 type mockReopenReporter struct {
 }
@@ -92,33 +107,30 @@ func (m *mockReopenReporter) Report(pType string, filename string, reason holmes
 
 	switch pType {
 	case "goroutine":
-		grReopenReportCount++
+		atomic.AddInt32(&grReopenReportCount, 1)
 	}
 
 	if len(reason.String()) == 0 { // unknown reason type
-		unknownReasonTypeErr = fmt.Errorf("reopen reporter: unknown reason type")
-		return unknownReasonTypeErr
+		return setErr(&unknownReasonTypeErr, fmt.Errorf("reopen reporter: unknown reason type"))
 	}
 
 	{ // test scene
 		errPrefix := "reopen reporter: scene exception ==> "
 		if scene.TriggerAbs == 0 {
-			sceneException = fmt.Errorf(errPrefix + "abs in configuration is 0")
-			return sceneException
+			return setErr(&sceneException, fmt.Errorf(errPrefix+"abs in configuration is 0"))
 		}
 		if scene.TriggerDiff == 0 {
-			sceneException = fmt.Errorf(errPrefix + "diff in configuration is 0")
-			return sceneException
+			return setErr(&sceneException, fmt.Errorf(errPrefix+"diff in configuration is 0"))
 		}
 	}
 	return nil
 }
 
 func TestReporter(t *testing.T) {
-	grReportCount = 0
-	cpuReportCount = 0
-	unknownReasonTypeErr = nil
-	sceneException = nil
+	atomic.StoreInt32(&grReportCount, 0)
+	atomic.StoreInt32(&cpuReportCount, 0)
+	setErr(&unknownReasonTypeErr, nil) // nolint: errcheck
+	setErr(&sceneException, nil)       // nolint: errcheck
 
 	r := &mockReporter{}
 	err := h.Set(
@@ -133,40 +145,49 @@ func TestReporter(t *testing.T) {
 	go cpuex()
 	time.Sleep(10 * time.Second)
 
-	if grReportCount == 0 {
+	if atomic.LoadInt32(&grReportCount) == 0 {
 		log.Fatalf("not grReport")
 	}
 
-	if cpuReportCount == 0 {
+	if atomic.LoadInt32(&cpuReportCount) == 0 {
 		log.Fatalf("not cpuReport")
 	}
 
-	if unknownReasonTypeErr != nil {
-		log.Fatalf(unknownReasonTypeErr.Error())
+	if err := getErr(&unknownReasonTypeErr); err != nil {
+		log.Fatalf(err.Error())
 	}
 
-	if sceneException != nil {
-		log.Fatalf(sceneException.Error())
+	if err := getErr(&sceneException); err != nil {
+		log.Fatalf(err.Error())
 	}
+}
 
-	// test reopen feature
+// TestReporterRestart tests the reporter after restarted, it's warming up again with empty stats,
+// and dumps by the abs rule since the goroutine number is stable.
+func TestReporterRestart(t *testing.T) {
 	h.Stop()
-	h.Start()
-	grReopenReportCount = 0
+	atomic.StoreInt32(&grReopenReportCount, 0)
 	_ = h.Set(
-		holmes.WithProfileReporter(&mockReopenReporter{}))
-	time.Sleep(10 * time.Second)
+		holmes.WithProfileReporter(&mockReopenReporter{}),
+		holmes.WithGoroutineDump(5, 10, 6, 90, time.Second),
+		holmes.WithCollectInterval("1s"))
+	// not to wait for the cpu dump sampling.
+	h.DisableCPUDump()
+	h.Start()
+	defer h.EnableCPUDump()
 
-	time.Sleep(5 * time.Second)
+	for i := 0; i < 20 && atomic.LoadInt32(&grReopenReportCount) == 0; i++ {
+		time.Sleep(time.Second)
+	}
 
-	if grReopenReportCount == 0 {
+	if atomic.LoadInt32(&grReopenReportCount) == 0 {
 		log.Fatalf("fail to reopen")
 	}
 }
 
 func TestReporterReopen(t *testing.T) {
-	grReportCount = 0
-	cpuReportCount = 0
+	atomic.StoreInt32(&grReportCount, 0)
+	atomic.StoreInt32(&cpuReportCount, 0)
 	r := &mockReporter{}
 	err := h.Set(
 		holmes.WithProfileReporter(r),
@@ -181,11 +202,11 @@ func TestReporterReopen(t *testing.T) {
 	go cpuex()
 	time.Sleep(10 * time.Second)
 
-	if grReportCount == 0 {
+	if atomic.LoadInt32(&grReportCount) == 0 {
 		log.Fatalf("not grReport")
 	}
 
-	if cpuReportCount == 0 {
+	if atomic.LoadInt32(&cpuReportCount) == 0 {
 		log.Fatalf("not cpuReport")
 	}
 
@@ -194,14 +215,14 @@ func TestReporterReopen(t *testing.T) {
 
 	h.EnableProfileReporter()
 
-	grReopenReportCount = 0
+	atomic.StoreInt32(&grReopenReportCount, 0)
 	_ = h.Set(
 		holmes.WithProfileReporter(&mockReopenReporter{}))
 	time.Sleep(10 * time.Second)
 
 	time.Sleep(5 * time.Second)
 
-	if grReopenReportCount == 0 {
+	if atomic.LoadInt32(&grReopenReportCount) == 0 {
 		log.Fatalf("fail to reopen")
 	}
 }
@@ -59,6 +59,17 @@ func (r *ring) avg() int {
 	return r.sum / len(r.data)
 }
 
+// latest returns the latest pushed value, false when the ring is empty.
+func (r *ring) latest() (int, bool) {
+	if len(r.data) == 0 {
+		return 0, false
+	}
+	if len(r.data) < r.maxLen {
+		return r.data[len(r.data)-1], true
+	}
+	return r.data[(r.idx+r.maxLen-1)%r.maxLen], true
+}
+
 func (r *ring) sequentialData() []int {
 	index := r.idx
 	slice := make([]int, r.maxLen)
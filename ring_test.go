@@ -99,3 +99,16 @@ func Test_ring_humanData(t *testing.T) {
 		assert.Equal(t, r.sequentialData(), cases[i].except)
 	}
 }
+
+func TestRingLatest(t *testing.T) {
+	r := newRing(3)
+	_, ok := r.latest()
+	assert.False(t, ok)
+
+	for i := 1; i <= 5; i++ {
+		r.push(i)
+		v, ok := r.latest()
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignalDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes-signal")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "events.log")
+
+	hm, err := New(
+		WithCollectInterval("1h"),
+		WithCPUSamplingTime("100ms"),
+		WithDumpPath(dir),
+		WithEventLog(path, 0, 1),
+		WithSignalDump(),
+	)
+	assert.Nil(t, err)
+	hm.Start()
+	defer hm.Shutdown(context.Background()) // nolint: errcheck
+
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	// the event is logged after all the profiles are written.
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	events := readCheckEvents(t, path)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "signal", events[0].Check)
+	assert.Equal(t, "signal-0", events[0].EventID)
+	assert.Equal(t, ReasonSignal.String(), events[0].Reason)
+	assert.Equal(t, 4, len(events[0].Files))
+}
@@ -0,0 +1,270 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricSample is the usage collected in a cycle of the dump loop,
+// it's recorded by WithMetricsRecord and replayed by Simulate.
+type MetricSample struct {
+	Time time.Time `json:"time"`
+	// cpu and memory usage in percent.
+	CPU       int `json:"cpu"`
+	Mem       int `json:"mem"`
+	Goroutine int `json:"goroutine"`
+	Thread    int `json:"thread"`
+}
+
+// SimulatedDump is a dump which would have fired when replaying the samples.
+type SimulatedDump struct {
+	Time time.Time
+	// the check name, cpu, mem, goroutine or thread.
+	Type   string
+	CurVal int
+	// the average of the previous values, including the current one.
+	Avg    int
+	Reason ReasonType
+}
+
+// simulatedCheck is the state of a check type in the simulation.
+type simulatedCheck struct {
+	typ      configureType
+	stats    ring
+	coolDown time.Time
+	enabled  bool
+}
+
+// Simulate replays the samples through the trigger rules, cooldowns, warm-up and CPUMaxPercent
+// of the dump loop with the options, and returns the dumps which would have fired.
+// types are the check names to simulate, in cpu, mem, goroutine and thread, all of them when it's empty.
+// the time of the sample is used as the clock, it's the previous one plus CollectInterval when it's zero.
+func Simulate(samples []MetricSample, types []string, opts ...Option) ([]SimulatedDump, error) {
+	o := newOptions()
+	for _, opt := range opts {
+		if err := opt.apply(o); err != nil {
+			return nil, err
+		}
+	}
+
+	checks := map[configureType]*simulatedCheck{}
+	for _, typ := range []configureType{mem, cpu, thread, goroutine} {
+		checks[typ] = &simulatedCheck{typ: typ, stats: newRing(minCollectCyclesBeforeDumpStart)}
+	}
+	for _, name := range types {
+		found := false
+		for typ, c := range checks {
+			if check2name[typ] == name {
+				c.enabled, found = true, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown check type %q, must be one of cpu, mem, goroutine and thread", name)
+		}
+	}
+	if len(types) == 0 {
+		for _, c := range checks {
+			c.enabled = true
+		}
+	}
+
+	var dumps []SimulatedDump
+	var now time.Time
+	for i, s := range samples {
+		if !s.Time.IsZero() {
+			now = s.Time
+		} else if i > 0 {
+			now = now.Add(o.CollectInterval)
+		}
+
+		values := map[configureType]int{mem: s.Mem, cpu: s.CPU, thread: s.Thread, goroutine: s.Goroutine}
+		for typ, c := range checks {
+			c.stats.push(values[typ])
+		}
+
+		// same as the dump loop, warm up and skip all when cpu is too high.
+		if i+1 < minCollectCyclesBeforeDumpStart {
+			continue
+		}
+		if o.CPUMaxPercent != 0 && s.CPU >= o.CPUMaxPercent {
+			continue
+		}
+
+		for _, typ := range []configureType{mem, cpu, thread, goroutine} {
+			c := checks[typ]
+			if !c.enabled || c.coolDown.After(now) {
+				continue
+			}
+
+			var rule typeOption
+			ruleMax := NotSupportTypeMaxConfig
+			switch typ {
+			case mem:
+				rule = *o.memOpts
+			case cpu:
+				rule = *o.cpuOpts
+			case thread:
+				rule = *o.threadOpts
+			case goroutine:
+				rule = *o.grOpts.typeOption
+				ruleMax = o.grOpts.GoroutineTriggerNumMax
+			}
+
+			match, reason := matchRule(c.stats, values[typ], rule.TriggerMin, rule.TriggerAbs, rule.TriggerDiff, ruleMax)
+			if !match {
+				continue
+			}
+			dumps = append(dumps, SimulatedDump{
+				Time:   now,
+				Type:   check2name[typ],
+				CurVal: values[typ],
+				Avg:    c.stats.avg(),
+				Reason: reason,
+			})
+			c.coolDown = now.Add(rule.CoolDown)
+
+			// thread dump contains the goroutines, skip goroutine dump.
+			if typ == thread && checks[goroutine].enabled {
+				checks[goroutine].coolDown = now.Add(o.grOpts.CoolDown)
+			}
+		}
+	}
+	return dumps, nil
+}
+
+// ReadMetricSamples reads the samples in json lines written by WithMetricsRecord, a json array,
+// or csv with the header of any columns in time, cpu, mem, goroutine and thread.
+// the time in csv is in RFC3339 or unix seconds.
+func ReadMetricSamples(r io.Reader) ([]MetricSample, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	switch data[0] {
+	case '[':
+		var samples []MetricSample
+		if err := json.Unmarshal(data, &samples); err != nil {
+			return nil, err
+		}
+		return samples, nil
+	case '{':
+		var samples []MetricSample
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			text := bytes.TrimSpace(scanner.Bytes())
+			if len(text) == 0 {
+				continue
+			}
+			var s MetricSample
+			if err := json.Unmarshal(text, &s); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			samples = append(samples, s)
+		}
+		return samples, scanner.Err()
+	}
+	return readMetricSamplesCSV(data)
+}
+
+func readMetricSamplesCSV(data []byte) ([]MetricSample, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	samples := make([]MetricSample, 0, len(records)-1)
+	for line, record := range records[1:] {
+		var s MetricSample
+		for i, field := range record {
+			field = strings.TrimSpace(field)
+			if i >= len(header) || field == "" {
+				continue
+			}
+			if header[i] == "time" {
+				if s.Time, err = parseSampleTime(field); err != nil {
+					return nil, fmt.Errorf("line %d: %w", line+2, err)
+				}
+				continue
+			}
+
+			var v *int
+			switch header[i] {
+			case "cpu":
+				v = &s.CPU
+			case "mem":
+				v = &s.Mem
+			case "goroutine":
+				v = &s.Goroutine
+			case "thread":
+				v = &s.Thread
+			default:
+				continue
+			}
+			if *v, err = strconv.Atoi(field); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %v: %w", line+2, header[i], err)
+			}
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+func parseSampleTime(s string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// recordMetrics appends the sample to the metrics record file in json lines, it's rotated by size.
+func (h *Holmes) recordMetrics(s MetricSample) {
+	opts := h.opts.GetMetricsRecordOpts()
+	if opts.Path == "" {
+		return
+	}
+
+	line, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	if err := h.metricsLog.write(opts, line); err != nil {
+		h.Errorf("[Holmes] failed to record metrics to %v: %v", opts.Path, err)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	var samples []MetricSample
+	for i := 0; i < 30; i++ {
+		s := MetricSample{CPU: 10, Mem: 20, Goroutine: 100, Thread: 20}
+		switch i {
+		case 5, 12, 13, 25:
+			s.CPU = 90
+		case 20:
+			s.Thread = 100
+			s.Goroutine = 5000
+		}
+		samples = append(samples, s)
+	}
+	samples[0].Time = start
+
+	dumps, err := Simulate(samples, nil,
+		WithCollectInterval("5s"),
+		WithCPUDump(10, 25, 80, time.Minute),
+		WithThreadDump(10, 25, 70, time.Minute),
+		WithGoroutineDump(3000, 20, 200000, 0, time.Minute))
+	assert.Nil(t, err)
+
+	// the spike in warming up is skipped, the one in cooldown is skipped,
+	// and the goroutine dump is skipped after the thread dump.
+	assert.Equal(t, []SimulatedDump{
+		{Time: start.Add(60 * time.Second), Type: "cpu", CurVal: 90, Avg: 26, Reason: ReasonCurGreaterAbs},
+		{Time: start.Add(100 * time.Second), Type: "thread", CurVal: 100, Avg: 28, Reason: ReasonCurGreaterAbs},
+		{Time: start.Add(125 * time.Second), Type: "cpu", CurVal: 90, Avg: 18, Reason: ReasonCurGreaterAbs},
+	}, dumps)
+
+	// the goroutine dump fires when thread isn't simulated, all dumps are skipped when cpu >= CPUMaxPercent.
+	dumps, err = Simulate(samples, []string{"goroutine", "cpu"}, WithCPUMax(80),
+		WithGoroutineDump(3000, 20, 200000, 0, time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dumps))
+	assert.Equal(t, "goroutine", dumps[0].Type)
+
+	_, err = Simulate(samples, []string{"gcheap"})
+	assert.NotNil(t, err)
+}
+
+func TestReadMetricSamples(t *testing.T) {
+	want := []MetricSample{
+		{Time: time.Unix(1700000000, 0), CPU: 10, Mem: 20, Goroutine: 100, Thread: 30},
+		{Time: time.Unix(1700000005, 0), CPU: 90, Mem: 20, Goroutine: 100},
+	}
+	equal := func(samples []MetricSample) {
+		assert.Equal(t, len(want), len(samples))
+		for i := range samples {
+			assert.True(t, want[i].Time.Equal(samples[i].Time))
+			samples[i].Time = want[i].Time
+		}
+		assert.Equal(t, want, samples)
+	}
+
+	samples, err := ReadMetricSamples(strings.NewReader("time,cpu,mem,goroutine,thread\n1700000000,10,20,100,30\n2023-11-14T22:13:25Z,90,20,100,\n"))
+	assert.Nil(t, err)
+	equal(samples)
+
+	samples, err = ReadMetricSamples(strings.NewReader(`[{"time":"2023-11-14T22:13:20Z","cpu":10,"mem":20,"goroutine":100,"thread":30},{"time":"2023-11-14T22:13:25Z","cpu":90,"mem":20,"goroutine":100}]`))
+	assert.Nil(t, err)
+	equal(samples)
+
+	_, err = ReadMetricSamples(strings.NewReader("cpu,mem\nhigh,20\n"))
+	assert.NotNil(t, err)
+}
+
+func TestRecordMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "metrics.json")
+	h, err := New(WithMetricsRecord(path, 0, 1))
+	assert.Nil(t, err)
+
+	now := time.Unix(1700000000, 0)
+	h.recordMetrics(MetricSample{Time: now, CPU: 10, Mem: 20, Goroutine: 100, Thread: 30})
+	h.recordMetrics(MetricSample{Time: now.Add(5 * time.Second), CPU: 90})
+
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer f.Close() // nolint: errcheck
+	samples, err := ReadMetricSamples(f)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(samples))
+	assert.True(t, now.Equal(samples[0].Time))
+	assert.Equal(t, 30, samples[0].Thread)
+	assert.Equal(t, 90, samples[1].CPU)
+}
+
+func TestRecordMetricsRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holmes")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "metrics.json")
+
+	sample := MetricSample{Time: time.Unix(1700000000, 0), CPU: 10}
+	line, err := json.Marshal(sample)
+	assert.Nil(t, err)
+	// rotated after every two samples.
+	h, err := New(WithMetricsRecord(path, int64(2*(len(line)+1)), 1))
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		h.recordMetrics(sample)
+	}
+	assert.Nil(t, h.Shutdown(context.Background()))
+
+	count := func(name string) int {
+		f, err := os.Open(name)
+		assert.Nil(t, err)
+		defer f.Close() // nolint: errcheck
+		samples, err := ReadMetricSamples(f)
+		assert.Nil(t, err)
+		return len(samples)
+	}
+	assert.Equal(t, 1, count(path))
+	assert.Equal(t, 2, count(path+".1"))
+
+	_, err = New(WithMetricsRecord(path, 0, -1))
+	assert.NotNil(t, err)
+}
@@ -62,26 +62,6 @@ func readUint(path string) (uint64, error) {
 	return parseUint(strings.TrimSpace(string(v)), 10, 64)
 }
 
-// only reserve the top n.
-func trimResultTop(buffer bytes.Buffer) []byte {
-	index := TrimResultTopN
-	arr := strings.SplitN(buffer.String(), "\n\n", TrimResultTopN+1)
-
-	if len(arr) <= TrimResultTopN {
-		index = len(arr) - 1
-	}
-
-	return []byte(strings.Join(arr[:index], "\n\n"))
-}
-
-// only reserve the front n bytes
-func trimResultFront(buffer bytes.Buffer) []byte {
-	if buffer.Len() <= TrimResultMaxBytes {
-		return buffer.Bytes()
-	}
-	return buffer.Bytes()[:TrimResultMaxBytes-1]
-}
-
 // return values:
 // 1. cpu percent, not division cpu cores yet,
 // 2. RSS mem in bytes,
@@ -146,6 +126,26 @@ func getNormalMemoryLimit() (uint64, error) {
 	return machineMemory.Total, nil
 }
 
+// estimateHeapMarked estimates the heap marked by the last GC cycle by the GOGC percent,
+// heap goal = heap marked * (1 + GOGC/100).
+// Notice: the heap goal may be capped by the soft memory limit,
+// the estimated value is smaller than the actual one in this case.
+func estimateHeapMarked(memStats *runtime.MemStats, gcPercent int) uint64 {
+	// GC is off, there is no heap goal.
+	if gcPercent <= 0 {
+		return memStats.HeapAlloc
+	}
+	return memStats.NextGC * 100 / uint64(100+gcPercent)
+}
+
+// calcAllocRate returns the allocation rate in MB/s.
+func calcAllocRate(prevTotalAlloc, curTotalAlloc uint64, elapsed time.Duration) int {
+	if elapsed <= 0 || curTotalAlloc < prevTotalAlloc {
+		return 0
+	}
+	return int(float64(curTotalAlloc-prevTotalAlloc) / elapsed.Seconds() / (1 << 20))
+}
+
 func getThreadNum() int {
 	return pprof.Lookup("threadcreate").Count()
 }
@@ -219,21 +219,37 @@ func getBinaryFileNameAndCreate(dump string, dumpType configureType, eventID str
 	return f, filePath, err
 }
 
-func writeFile(data bytes.Buffer, dumpType configureType, dumpOpts *DumpOptions, eventID string) (string, error) {
-	var buf []byte
-	if dumpOpts.DumpProfileType == textDump && !dumpOpts.DumpFullStack {
-		switch dumpType {
-		case mem, gcHeap, goroutine:
-			buf = trimResultTop(data)
-		case thread:
-			buf = trimResultFront(data)
-		default:
-			buf = data.Bytes()
-		}
-	} else {
-		buf = data.Bytes()
+// rendersTextTop returns whether the profile of the dump type is written as a top n report.
+func (o *DumpOptions) rendersTextTop(dumpType configureType) bool {
+	if o.DumpProfileType != textDump || o.DumpFullStack {
+		return false
+	}
+	switch dumpType {
+	case mem, gcHeap, goroutine, thread:
+		return true
+	}
+	return false
+}
+
+// profileDebug returns the debug level to capture the profile of the dump type,
+// the profile rendered as a top n report is captured in binary, so the report and the file are of the same capture.
+func (o *DumpOptions) profileDebug(dumpType configureType) int {
+	if o.rendersTextTop(dumpType) {
+		return int(binaryDump)
 	}
+	return int(o.DumpProfileType)
+}
+
+// renderProfile returns the bytes to write of the captured profile, it's rendered as a top n report in text mode.
+func (o *DumpOptions) renderProfile(data bytes.Buffer, dumpType configureType) []byte {
+	if o.rendersTextTop(dumpType) {
+		return renderTextTop(data, o)
+	}
+	return data.Bytes()
+}
 
+// writeFile writes the profile to the dump path, and flushes it to disk when fsync is true.
+func writeFile(buf []byte, dumpType configureType, dumpOpts *DumpOptions, eventID string, fsync bool) (string, error) {
 	file, fileName, err := getBinaryFileNameAndCreate(dumpOpts.DumpPath, dumpType, eventID)
 	if err != nil {
 		return fileName, fmt.Errorf("pprof %v open file failed : %w", type2name[dumpType], err)
@@ -243,5 +259,10 @@ func writeFile(data bytes.Buffer, dumpType configureType, dumpOpts *DumpOptions,
 	if _, err = file.Write(buf); err != nil {
 		return fileName, fmt.Errorf("pprof %v write to file failed : %w", type2name[dumpType], err)
 	}
+	if fsync {
+		if err = file.Sync(); err != nil {
+			return fileName, fmt.Errorf("pprof %v sync file failed : %w", type2name[dumpType], err)
+		}
+	}
 	return fileName, nil
 }
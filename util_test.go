@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package holmes
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateHeapMarked(t *testing.T) {
+	memStats := &runtime.MemStats{NextGC: 300, HeapAlloc: 10}
+
+	assert.Equal(t, uint64(100), estimateHeapMarked(memStats, 200))
+	assert.Equal(t, uint64(200), estimateHeapMarked(memStats, 50))
+
+	// GC is off, use the heap alloc
+	assert.Equal(t, uint64(10), estimateHeapMarked(memStats, -1))
+}
+
+func TestCalcAllocRate(t *testing.T) {
+	assert.Equal(t, 0, calcAllocRate(0, 100<<20, 0))
+	assert.Equal(t, 0, calcAllocRate(100<<20, 0, time.Second))
+	assert.Equal(t, 100, calcAllocRate(0, 100<<20, time.Second))
+	assert.Equal(t, 20, calcAllocRate(100<<20, 200<<20, 5*time.Second))
+}